@@ -1,7 +1,11 @@
 package lite
 
 import (
+	"context"
 	"net/http"
+	"net/url"
+	"path"
+	"reflect"
 
 	mw "github.com/tiny-go/middleware"
 )
@@ -19,11 +23,11 @@ func getSingle(controller SingleGetter) http.HandlerFunc {
 		// call the controller action
 		data, err := controller.Get(r.Context(), ParamsFromContext(r.Context())["pk"])
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
 		// send the success response
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		send(w, r, http.StatusOK, data)
 	}
 }
 
@@ -33,121 +37,127 @@ func getPlural(controller PluralGetter) http.HandlerFunc {
 		// call the controller action
 		data, err := controller.GetAll(r.Context(), r.URL.Query())
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
 		// send data to the client
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		send(w, r, http.StatusOK, data)
 	}
 }
 
 // postSingle handles single POST request on provided resource.
 func postSingle(controller SinglePoster) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		decode, cleanup := decoder(r)
+		defer cleanup()
 		// call the controller action
-		data, err := controller.Post(r.Context(), func(v interface{}) error {
-			return mw.RequestCodecFromContext(r.Context()).Decoder(r.Body).Decode(v)
-		})
+		data, err := controller.Post(r.Context(), decode)
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
+		// the model is available by request URL
+		w.Header().Set("Location", r.URL.Path)
 		// send data to the client
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		send(w, r, http.StatusCreated, data)
 	}
 }
 
 // postPlural handles bulk POST request on provided resource.
 func postPlural(controller PluralPoster) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		decode, cleanup := decoder(r)
+		defer cleanup()
 		// call the controller action
-		data, err := controller.PostAll(r.Context(), func(v interface{}) error {
-			return mw.RequestCodecFromContext(r.Context()).Decoder(r.Body).Decode(v)
-		})
+		data, err := controller.PostAll(r.Context(), decode)
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
+		}
+		// the model is available by its primary key (if provided)
+		if model, ok := body(data).(Identifier); ok {
+			w.Header().Set("Location", path.Join(r.URL.Path, url.PathEscape(model.PrimaryKey())))
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
 		// send data to the client
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		send(w, r, http.StatusCreated, data)
 	}
 }
 
 // patchSingle handles single PATCH request on provided resource.
 func patchSingle(controller SinglePatcher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		decode, cleanup := decoder(r)
+		defer cleanup()
 		// call the controller action
-		data, err := controller.Patch(r.Context(), ParamsFromContext(r.Context())["pk"], func(v interface{}) error {
-			return mw.RequestCodecFromContext(r.Context()).Decoder(r.Body).Decode(v)
-		})
+		data, err := controller.Patch(r.Context(), ParamsFromContext(r.Context())["pk"], decode)
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
 		// send data to the client
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		send(w, r, http.StatusOK, data)
 	}
 }
 
 // patchPlural handles bulk PATCH request on provided resource.
 func patchPlural(controller PluralPatcher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		decode, cleanup := decoder(r)
+		defer cleanup()
 		// call the controller action
-		data, err := controller.PatchAll(r.Context(), r.URL.Query(), func(v interface{}) error {
-			return mw.RequestCodecFromContext(r.Context()).Decoder(r.Body).Decode(v)
-		})
+		data, err := controller.PatchAll(r.Context(), r.URL.Query(), decode)
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
 		// send data to the client
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		send(w, r, http.StatusOK, data)
 	}
 }
 
 // putSingle handles single PUT request on provided resource.
 func putSingle(controller SinglePutter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		decode, cleanup := decoder(r)
+		defer cleanup()
 		// call the controller action
-		data, err := controller.Put(r.Context(), ParamsFromContext(r.Context())["pk"], func(v interface{}) error {
-			return mw.RequestCodecFromContext(r.Context()).Decoder(r.Body).Decode(v)
-		})
+		data, err := controller.Put(r.Context(), ParamsFromContext(r.Context())["pk"], decode)
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
-		// send data to the client
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		// send data to the client (no content if action returned nothing)
+		send(w, r, emptyStatus(data), data)
 	}
 }
 
 // putPlural handles bulk PUT request for provided resource.
 func putPlural(controller PluralPutter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		decode, cleanup := decoder(r)
+		defer cleanup()
 		// call the controller action
-		data, err := controller.PutAll(r.Context(), r.URL.Query(), func(v interface{}) error {
-			return mw.RequestCodecFromContext(r.Context()).Decoder(r.Body).Decode(v)
-		})
+		data, err := controller.PutAll(r.Context(), r.URL.Query(), decode)
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
-		// send data to the client
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		// send data to the client (no content if action returned nothing)
+		send(w, r, emptyStatus(data), data)
 	}
 }
 
 // deleteSingle handles single DELETE request on provided resource.
 func deleteSingle(controller SingleDeleter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// delete model by primary key(s) TODO: primary is missing
+		// delete model by primary key(s)
 		data, err := controller.Delete(r.Context(), ParamsFromContext(r.Context())["pk"])
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
-		// send data to the client
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		// send data to the client (no content if action returned nothing)
+		send(w, r, emptyStatus(data), data)
 	}
 }
 
@@ -157,10 +167,131 @@ func deletePlural(controller PluralDeleter) http.HandlerFunc {
 		// call the controller action
 		data, err := controller.DeleteAll(r.Context(), r.URL.Query())
 		if err != nil {
-			panic(err)
+			RenderError(w, r, err)
+			return
+		}
+		// send data to the client (no content if action returned nothing)
+		send(w, r, emptyStatus(data), data)
+	}
+}
+
+// singleAction handles custom action request on a single resource.
+func singleAction(action func(context.Context, string, func(interface{}) error) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decode, cleanup := decoder(r)
+		defer cleanup()
+		// call the controller action
+		data, err := action(r.Context(), ParamsFromContext(r.Context())["pk"], decode)
+		if err != nil {
+			RenderError(w, r, err)
+			return
 		}
-		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
 		// send data to the client
-		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+		send(w, r, http.StatusOK, data)
+	}
+}
+
+// pluralAction handles custom bulk action request on provided resource.
+func pluralAction(action func(context.Context, url.Values, func(interface{}) error) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decode, cleanup := decoder(r)
+		defer cleanup()
+		// call the controller action
+		data, err := action(r.Context(), r.URL.Query(), decode)
+		if err != nil {
+			RenderError(w, r, err)
+			return
+		}
+		// send data to the client
+		send(w, r, http.StatusOK, data)
+	}
+}
+
+// emptyStatus returns 204 status code if there is no data to send, otherwise 200.
+func emptyStatus(data interface{}) int {
+	if isNil(data) {
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+// isNil reports whether the data is nil (including typed nil pointers, maps and
+// slices returned as interface{}, for instance by typed controllers).
+func isNil(data interface{}) bool {
+	if data == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(data); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// body retrieves the response body if data is wrapped with Response.
+func body(data interface{}) interface{} {
+	if res, ok := data.(*Response); ok {
+		return res.Body
+	}
+	return data
+}
+
+// send writes the data to the client with provided status code using response
+// codec (Blob is written as is). If data is a Response wrapper its status code,
+// headers and body are used instead. If encoding fails before anything has been
+// written to the client the error response is sent instead.
+func send(w http.ResponseWriter, r *http.Request, code int, data interface{}) {
+	if res, ok := data.(*Response); ok {
+		for key, values := range res.Header {
+			w.Header()[http.CanonicalHeaderKey(key)] = values
+		}
+		if res.Status != 0 {
+			code = res.Status
+		}
+		if data = res.Body; isNil(data) {
+			w.WriteHeader(code)
+			return
+		}
+	}
+	if blob, ok := data.(*Blob); ok {
+		blob.serve(w, r, code)
+		return
+	}
+	if code == http.StatusNoContent {
+		w.WriteHeader(code)
+		return
+	}
+	w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
+	// postpone writing the status code until the first chunk of data is encoded
+	dw := &deferredWriter{ResponseWriter: w, code: code}
+	if err := mw.ResponseCodecFromContext(r.Context()).Encoder(dw).Encode(data); err != nil {
+		if !dw.written {
+			w.Header().Del("Content-Type")
+			RenderError(w, r, err)
+		}
+		return
+	}
+	dw.writeHeader()
+}
+
+// deferredWriter postpones writing the status code to the response until the
+// first write of the body (headers can still be changed until then).
+type deferredWriter struct {
+	http.ResponseWriter
+	code    int
+	written bool
+}
+
+// Write writes the status code (only once) and the data to the response.
+func (dw *deferredWriter) Write(b []byte) (int, error) {
+	dw.writeHeader()
+	return dw.ResponseWriter.Write(b)
+}
+
+// writeHeader writes the status code if it has not been written yet.
+func (dw *deferredWriter) writeHeader() {
+	if !dw.written {
+		dw.written = true
+		dw.ResponseWriter.WriteHeader(dw.code)
 	}
 }
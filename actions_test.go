@@ -0,0 +1,213 @@
+package lite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	"github.com/tiny-go/errors"
+	mw "github.com/tiny-go/middleware"
+)
+
+func Test_Responses(t *testing.T) {
+	t.Run("Given an HTTP handler with controllers returning different data", func(t *testing.T) {
+		driver.Default("application/json")
+		module := NewBaseModule()
+		module.Register("nil", &mockDataController{mw.NewBaseController(), nil})
+		module.Register("typednil", &mockDataController{mw.NewBaseController(), (*mockModel)(nil)})
+		module.Register("nilslice", &mockDataController{mw.NewBaseController(), []*mockModel(nil)})
+		module.Register("model", &mockDataController{mw.NewBaseController(), &mockModel{"abcd"}})
+		module.Register("wrapped", &mockDataController{mw.NewBaseController(), &Response{
+			Status: http.StatusAccepted,
+			Header: http.Header{"X-Foo": []string{"bar"}},
+			Body:   &mockModel{"abcd"},
+		}})
+		module.Register("invalid", &mockDataController{mw.NewBaseController(), make(chan int)})
+		modified := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		module.Register("blob", &mockDataController{mw.NewBaseController(), &Blob{
+			Name:    "report.txt",
+			ModTime: modified,
+			Content: strings.NewReader("hello world"),
+		}})
+		handler := NewHandler(WithLogger(DiscardLogger))
+		if err := handler.Use("test", module); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		type testCase struct {
+			title   string
+			method  string
+			path    string
+			request http.Header
+			code    int
+			header  http.Header
+			body    string
+		}
+		testCases := []testCase{
+			{
+				title:  "DELETE returning nil should respond with no content",
+				method: http.MethodDelete,
+				path:   "/test/nil/abcd",
+				code:   http.StatusNoContent,
+			},
+			{
+				title:  "DELETE returning typed nil pointer should respond with no content",
+				method: http.MethodDelete,
+				path:   "/test/typednil/abcd",
+				code:   http.StatusNoContent,
+			},
+			{
+				title:  "DELETE returning nil slice should respond with no content",
+				method: http.MethodDelete,
+				path:   "/test/nilslice/abcd",
+				code:   http.StatusNoContent,
+			},
+			{
+				title:  "POST should respond with location of the created model",
+				method: http.MethodPost,
+				path:   "/test/model",
+				code:   http.StatusCreated,
+				header: http.Header{"Location": []string{"/test/model/abcd"}},
+				body:   "{\"id\":\"abcd\"}\n",
+			},
+			{
+				title:  "response wrapper should override status code and headers",
+				method: http.MethodPost,
+				path:   "/test/wrapped",
+				code:   http.StatusAccepted,
+				header: http.Header{"X-Foo": []string{"bar"}, "Location": []string{"/test/wrapped/abcd"}},
+				body:   "{\"id\":\"abcd\"}\n",
+			},
+			{
+				title:  "encoding error should be sent to the client if nothing has been written",
+				method: http.MethodDelete,
+				path:   "/test/invalid/abcd",
+				code:   http.StatusInternalServerError,
+				body:   "Internal Server Error\n",
+			},
+			{
+				title:  "blob should be written as is",
+				method: http.MethodDelete,
+				path:   "/test/blob/abcd",
+				code:   http.StatusOK,
+				header: http.Header{
+					"Content-Type":        []string{"text/plain; charset=utf-8"},
+					"Content-Disposition": []string{"attachment; filename=report.txt"},
+					"Last-Modified":       []string{modified.Format(http.TimeFormat)},
+				},
+				body: "hello world",
+			},
+			{
+				title:  "blob should keep status code of the action",
+				method: http.MethodPost,
+				path:   "/test/blob",
+				code:   http.StatusCreated,
+				body:   "hello world",
+			},
+			{
+				title:   "blob should support range requests",
+				method:  http.MethodGet,
+				path:    "/test/blob/abcd",
+				request: http.Header{"Range": []string{"bytes=6-"}},
+				code:    http.StatusPartialContent,
+				header:  http.Header{"Content-Range": []string{"bytes 6-10/11"}},
+				body:    "world",
+			},
+			{
+				title:   "blob should support conditional requests",
+				method:  http.MethodGet,
+				path:    "/test/blob/abcd",
+				request: http.Header{"If-Modified-Since": []string{modified.Format(http.TimeFormat)}},
+				code:    http.StatusNotModified,
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.title, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(tc.method, tc.path, nil)
+				for key, values := range tc.request {
+					r.Header[key] = values
+				}
+				handler.ServeHTTP(w, r)
+				if w.Code != tc.code {
+					t.Errorf("status code %d was expected to be %d", w.Code, tc.code)
+				}
+				if w.Body.String() != tc.body {
+					t.Errorf("the output %q was expected to be %q", w.Body.String(), tc.body)
+				}
+				for key := range tc.header {
+					if w.Header().Get(key) != tc.header.Get(key) {
+						t.Errorf("unexpected value %q for header %q", w.Header().Get(key), key)
+					}
+				}
+			})
+		}
+	})
+}
+
+// panicGetSingle is a former (panic based) implementation of getSingle handler
+// kept for the benchmark comparison only.
+func panicGetSingle(controller SingleGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := controller.Get(r.Context(), ParamsFromContext(r.Context())["pk"])
+		if err != nil {
+			panic(err)
+		}
+		w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
+		panic(mw.ResponseCodecFromContext(r.Context()).Encoder(w).Encode(data))
+	}
+}
+
+func Benchmark_GetSingle(b *testing.B) {
+	driver.Default("application/json")
+	chain := mw.New(mw.PanicRecover(errors.Send), mw.Codec(nil, driver.Global()), GorillaParams)
+	handlers := map[string]http.Handler{
+		"panic":  chain.Then(panicGetSingle(newPassController())),
+		"writer": chain.Then(getSingle(newPassController())),
+	}
+	for _, name := range []string{"panic", "writer"} {
+		handler := handlers[name]
+		b.Run(name, func(b *testing.B) {
+			r := httptest.NewRequest(http.MethodGet, "/test/pass/abcd", nil)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				handler.ServeHTTP(httptest.NewRecorder(), r)
+			}
+		})
+	}
+}
+
+func Benchmark_Actions(b *testing.B) {
+	driver.Default("application/json")
+	module := NewBaseModule()
+	module.Register("pass", newPassController())
+	module.Register("fail", newFailController())
+	handler := NewHandler(WithLogger(DiscardLogger))
+	handler.Use("test", module)
+
+	type benchCase struct {
+		title  string
+		method string
+		path   string
+		body   string
+	}
+	benchCases := []benchCase{
+		{"single GET with success", http.MethodGet, "/test/pass/abcd", ""},
+		{"single GET with failure", http.MethodGet, "/test/fail/abcd", ""},
+		{"plural GET with success", http.MethodGet, "/test/pass?foo=bar", ""},
+		{"single POST with success", http.MethodPost, "/test/pass/abcd", "{\"foo\":\"bar\"}"},
+		{"single DELETE with success", http.MethodDelete, "/test/pass/abcd", ""},
+	}
+	for _, bc := range benchCases {
+		b.Run(bc.title, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(bc.method, bc.path, strings.NewReader(bc.body)))
+			}
+		})
+	}
+}
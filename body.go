@@ -0,0 +1,127 @@
+package lite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/errors"
+)
+
+// Decoding configures how request bodies are read and decoded.
+type Decoding struct {
+	// MaxBodySize limits the size of the request body (reading more responds with
+	// 413), zero means the limit of the parent is used and negative means no limit.
+	MaxBodySize int64
+	// Strict makes JSON decoding reject unknown fields and any data after the first
+	// value with 400 (other codecs are not affected), nil means the setting of the
+	// parent is used.
+	Strict *bool
+}
+
+// DecodingProvider can be implemented by the module or controller in order to
+// declare its own decoding settings (nil means settings of the parent are used).
+type DecodingProvider interface {
+	Decoding() *Decoding
+}
+
+// with returns the settings overridden by settings of provided module or controller
+// (if it implements DecodingProvider).
+func (d Decoding) with(v interface{}) Decoding {
+	provider, ok := v.(DecodingProvider)
+	if !ok {
+		return d
+	}
+	if own := provider.Decoding(); own != nil {
+		if own.MaxBodySize != 0 {
+			d.MaxBodySize = own.MaxBodySize
+		}
+		if own.Strict != nil {
+			d.Strict = own.Strict
+		}
+	}
+	return d
+}
+
+// strict reports whether JSON should be decoded strictly.
+func (d Decoding) strict() bool { return d.Strict != nil && *d.Strict }
+
+// routeConfig contains settings of the routes inherited from the handler by the
+// modules and controllers (which may override them).
+type routeConfig struct {
+	codecs   Codecs
+	decoding Decoding
+}
+
+// with returns the settings overridden by provided module or controller.
+func (rc routeConfig) with(v interface{}) routeConfig {
+	return routeConfig{rc.codecs.with(v), rc.decoding.with(v)}
+}
+
+type decodingKey struct{}
+
+// limitBody limits the size of the request body and passes decoding settings to
+// the actions. Requests declaring larger Content-Length are rejected right away.
+func limitBody(decoding Decoding, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limit := decoding.MaxBodySize; limit > 0 && r.Body != nil && r.Body != http.NoBody {
+			if r.ContentLength > limit {
+				RenderError(w, r, tooLarge(limit))
+				return
+			}
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{newLimitedReader(r.Body, limit), r.Body}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), decodingKey{}, decoding)))
+	})
+}
+
+// decodingFromContext returns decoding settings of the route.
+func decodingFromContext(ctx context.Context) Decoding {
+	decoding, _ := ctx.Value(decodingKey{}).(Decoding)
+	return decoding
+}
+
+// decode decodes the body with provided codec, JSON is decoded strictly if required.
+func decode(c codec.Codec, body io.Reader, strict bool, v interface{}) error {
+	if !strict || !isJSON(c.MimeType()) {
+		return c.Decoder(body).Decode(v)
+	}
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return strictError(err)
+	}
+	// the rest of the body may contain whitespace only
+	rest, err := io.ReadAll(io.MultiReader(dec.Buffered(), body))
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return errors.NewStatusError(http.StatusBadRequest,
+			fmt.Errorf("unexpected data after JSON value"))
+	}
+	return nil
+}
+
+// strictError converts decoding error to 400 (unless it already has a status code).
+func strictError(err error) error {
+	if _, ok := err.(errors.Error); ok {
+		return err
+	}
+	return errors.NewStatusError(http.StatusBadRequest, err)
+}
+
+// isJSON reports whether media type is JSON (including "+json" suffix).
+func isJSON(mimeType string) bool {
+	mediaType := strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0])
+	typ, subtype := splitMediaType(mediaType)
+	return typ == "application" && (subtype == "json" || strings.HasSuffix(subtype, "+json"))
+}
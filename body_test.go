@@ -0,0 +1,116 @@
+package lite
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	mw "github.com/tiny-go/middleware"
+)
+
+func Test_Decoding(t *testing.T) {
+	t.Run("Given a handler with body limit and controllers with own decoding settings", func(t *testing.T) {
+		driver.Default("application/json")
+		module := NewBaseModule()
+		module.Register("default", &mockDecodingController{Controller: mw.NewBaseController()})
+		module.Register("unlimited", &mockDecodingController{mw.NewBaseController(), &Decoding{MaxBodySize: -1}})
+		strict := true
+		module.Register("strict", &mockDecodingController{mw.NewBaseController(), &Decoding{Strict: &strict}})
+		handler := NewHandler(WithLogger(DiscardLogger), WithBodyLimit(16))
+		if err := handler.Use("test", module); err != nil {
+			t.Fatalf("module was expected to be registered: %v", err)
+		}
+
+		cases := []struct {
+			title    string
+			path     string
+			body     string
+			chunked  bool
+			code     int
+			response string
+		}{
+			{
+				"should decode body within the limit", "/test/default/1", `{"foo":"bar"}`, false,
+				http.StatusCreated, `{"foo":"bar"}` + "\n",
+			},
+			{
+				"should reject body with too large Content-Length", "/test/default/1", `{"foo":"barbazqux"}`, false,
+				http.StatusRequestEntityTooLarge, "request body is too large (limit is 16 bytes)\n",
+			},
+			{
+				"should reject too large body of unknown length", "/test/default/1", `{"foo":"barbazqux"}`, true,
+				http.StatusRequestEntityTooLarge, "request body is too large (limit is 16 bytes)\n",
+			},
+			{
+				"should ignore unknown fields by default", "/test/default/1", `{"bar":"foo"}`, false,
+				http.StatusCreated, `{"foo":""}` + "\n",
+			},
+			{
+				"should allow controller to disable the limit", "/test/unlimited/1", `{"foo":"barbazqux"}`, false,
+				http.StatusCreated, `{"foo":"barbazqux"}` + "\n",
+			},
+			{
+				"should inherit the limit of the handler if strict", "/test/strict/1", `{"foo":"barbazqux"}`, false,
+				http.StatusRequestEntityTooLarge, "request body is too large (limit is 16 bytes)\n",
+			},
+			{
+				"should reject unknown fields if strict", "/test/strict/1", `{"bar":"foo"}`, false,
+				http.StatusBadRequest, "json: unknown field \"bar\"\n",
+			},
+			{
+				"should reject trailing data if strict", "/test/strict/1", `{"foo":"a"} {}`, false,
+				http.StatusBadRequest, "unexpected data after JSON value\n",
+			},
+			{
+				"should allow trailing whitespace if strict", "/test/strict/1", "{\"foo\":\"a\"}\n", false,
+				http.StatusCreated, `{"foo":"a"}` + "\n",
+			},
+		}
+		for _, tc := range cases {
+			t.Run(tc.title, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+				if tc.chunked {
+					r.Body, r.ContentLength = ioutil.NopCloser(strings.NewReader(tc.body)), -1
+				}
+				r.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tc.code {
+					t.Errorf("status code %d was expected to be %d", w.Code, tc.code)
+				}
+				if w.Body.String() != tc.response {
+					t.Errorf("response %q was expected to be %q", w.Body.String(), tc.response)
+				}
+			})
+		}
+	})
+	t.Run("Given a strict handler and controllers overriding part of decoding settings", func(t *testing.T) {
+		driver.Default("application/json")
+		lenient := false
+		module := NewBaseModule()
+		module.Register("sized", &mockDecodingController{mw.NewBaseController(), &Decoding{MaxBodySize: 1 << 20}})
+		module.Register("lenient", &mockDecodingController{mw.NewBaseController(), &Decoding{Strict: &lenient}})
+		handler := NewHandler(WithLogger(DiscardLogger), WithStrictDecoding(true))
+		if err := handler.Use("test", module); err != nil {
+			t.Fatalf("module was expected to be registered: %v", err)
+		}
+		for path, code := range map[string]int{
+			"/test/sized/1":   http.StatusBadRequest,
+			"/test/lenient/1": http.StatusCreated,
+		} {
+			t.Run("should inherit strict setting unless overridden by "+path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"bar":"foo"}`))
+				r.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != code {
+					t.Errorf("status code %d was expected to be %d", w.Code, code)
+				}
+			})
+		}
+	})
+}
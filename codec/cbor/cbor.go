@@ -0,0 +1,38 @@
+// Package cbor provides CBOR (RFC 8949) codec (registered in the global codec
+// registry on import).
+package cbor
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/codec/driver"
+)
+
+// DataTypeCBOR contains CBOR codec data type.
+const DataTypeCBOR = "application/cbor"
+
+var (
+	global = &CBOR{}
+	// maps are decoded with string keys (as in JSON) when destination is interface{}
+	decMode, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}(nil))}.DecMode()
+)
+
+// CBOR codec (struct fields are named by "cbor" or "json" tags).
+type CBOR struct{}
+
+// Encoder creates CBOR encoder.
+func (c *CBOR) Encoder(w io.Writer) codec.Encoder { return cbor.NewEncoder(w) }
+
+// Decoder creates CBOR decoder.
+func (c *CBOR) Decoder(r io.Reader) codec.Decoder { return decMode.NewDecoder(r) }
+
+// MimeType returns the mime type of this codec (which is application/cbor).
+func (c *CBOR) MimeType() string { return DataTypeCBOR }
+
+func init() {
+	// codec is stateless, so the same instance can always be returned
+	driver.Register(DataTypeCBOR, func(string) codec.Codec { return global })
+}
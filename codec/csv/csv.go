@@ -0,0 +1,179 @@
+// Package csv provides CSV codec (registered in the global codec registry on
+// import). Structs (by "json" tags or field names) and maps (by sorted keys) are
+// encoded as rows with a header, string slices are encoded as rows without it.
+package csv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/codec/driver"
+)
+
+// DataTypeCSV contains CSV codec data type.
+const DataTypeCSV = "text/csv"
+
+var global = &CSV{}
+
+// CSV codec.
+type CSV struct{}
+
+// Encoder creates CSV encoder (every element of the slice is written as a row,
+// other values are written as a single row).
+func (c *CSV) Encoder(w io.Writer) codec.Encoder {
+	enc := c.StreamEncoder(w)
+	return codec.EncoderFunc(func(v interface{}) error {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem().Kind() == reflect.String {
+			return enc.Encode(v)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// StreamEncoder creates CSV encoder that writes every value as a single row (the
+// header is written before the first row of structs or maps).
+func (c *CSV) StreamEncoder(w io.Writer) codec.Encoder {
+	cw := csv.NewWriter(w)
+	var header []string
+	return codec.EncoderFunc(func(v interface{}) error {
+		columns, row, err := record(v)
+		if err != nil {
+			return err
+		}
+		if header == nil && columns != nil {
+			header = columns
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+		}
+		if columns != nil {
+			row = reorder(header, columns, row)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+// Decoder creates CSV decoder. Destination should be a pointer to [][]string (all
+// the rows), []string (a single row) or a slice of string maps (rows by header).
+func (c *CSV) Decoder(r io.Reader) codec.Decoder {
+	cr := csv.NewReader(r)
+	return codec.DecoderFunc(func(v interface{}) error {
+		switch dst := v.(type) {
+		case *[][]string:
+			rows, err := cr.ReadAll()
+			*dst = append(*dst, rows...)
+			return err
+		case *[]string:
+			row, err := cr.Read()
+			*dst = row
+			return err
+		case *[]map[string]string:
+			header, err := cr.Read()
+			if err != nil {
+				return err
+			}
+			for {
+				row, err := cr.Read()
+				if err == io.EOF {
+					return nil
+				} else if err != nil {
+					return err
+				}
+				item := make(map[string]string, len(header))
+				for i, column := range header {
+					if i < len(row) {
+						item[column] = row[i]
+					}
+				}
+				*dst = append(*dst, item)
+			}
+		}
+		return fmt.Errorf("csv: unsupported destination %T", v)
+	})
+}
+
+// MimeType returns the mime type of this codec (which is text/csv).
+func (c *CSV) MimeType() string { return DataTypeCSV }
+
+// record converts the value to CSV columns (nil if value has no named fields)
+// and the row.
+func record(v interface{}) ([]string, []string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil, fmt.Errorf("csv: unable to encode nil value")
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		row := make([]string, rv.Len())
+		for i := range row {
+			row[i] = fmt.Sprint(rv.Index(i).Interface())
+		}
+		return nil, row, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, nil, fmt.Errorf("csv: unsupported map key type %s", rv.Type().Key())
+		}
+		var columns []string
+		for _, key := range rv.MapKeys() {
+			columns = append(columns, key.String())
+		}
+		sort.Strings(columns)
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = fmt.Sprint(rv.MapIndex(reflect.ValueOf(column).Convert(rv.Type().Key())).Interface())
+		}
+		return columns, row, nil
+	case reflect.Struct:
+		var columns, row []string
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Type().Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if field.PkgPath != "" || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			columns = append(columns, name)
+			row = append(row, fmt.Sprint(rv.Field(i).Interface()))
+		}
+		return columns, row, nil
+	}
+	return nil, nil, fmt.Errorf("csv: unsupported type %s", rv.Type())
+}
+
+// reorder arranges the row by header columns (missing values are empty).
+func reorder(header, columns, row []string) []string {
+	values := make(map[string]string, len(columns))
+	for i, column := range columns {
+		values[column] = row[i]
+	}
+	ordered := make([]string, len(header))
+	for i, column := range header {
+		ordered[i] = values[column]
+	}
+	return ordered
+}
+
+func init() {
+	// codec is stateless, so the same instance can always be returned
+	driver.Register(DataTypeCSV, func(string) codec.Codec { return global })
+}
@@ -0,0 +1,42 @@
+// Package msgpack provides MessagePack codec (registered in the global codec
+// registry on import).
+package msgpack
+
+import (
+	"io"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/codec/driver"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// DataTypeMsgpack contains MessagePack codec data type.
+const DataTypeMsgpack = "application/msgpack"
+
+var global = &Msgpack{}
+
+// Msgpack codec (struct fields are named by "json" tags, so the same models can
+// be used with JSON codec).
+type Msgpack struct{}
+
+// Encoder creates MessagePack encoder.
+func (m *Msgpack) Encoder(w io.Writer) codec.Encoder {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc
+}
+
+// Decoder creates MessagePack decoder.
+func (m *Msgpack) Decoder(r io.Reader) codec.Decoder {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	return dec
+}
+
+// MimeType returns the mime type of this codec (which is application/msgpack).
+func (m *Msgpack) MimeType() string { return DataTypeMsgpack }
+
+func init() {
+	// codec is stateless, so the same instance can always be returned
+	driver.Register(DataTypeMsgpack, func(string) codec.Codec { return global })
+}
@@ -0,0 +1,76 @@
+// Package ndjson provides newline delimited JSON codec (registered in the global
+// codec registry on import). Slices are encoded (and decoded) as a sequence of
+// lines, so the codec can be used to stream the items one by one.
+package ndjson
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/codec/driver"
+)
+
+// DataTypeNDJSON contains NDJSON codec data type.
+const DataTypeNDJSON = "application/x-ndjson"
+
+var global = &NDJSON{}
+
+// NDJSON codec.
+type NDJSON struct{}
+
+// Encoder creates NDJSON encoder (every element of the slice is written as a
+// separate line, other values are written as a single line).
+func (n *NDJSON) Encoder(w io.Writer) codec.Encoder {
+	enc := json.NewEncoder(w)
+	return codec.EncoderFunc(func(v interface{}) error {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return enc.Encode(v)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// StreamEncoder creates NDJSON encoder that writes every value as a single line.
+func (n *NDJSON) StreamEncoder(w io.Writer) codec.Encoder { return json.NewEncoder(w) }
+
+// Decoder creates NDJSON decoder (all the lines are decoded if destination is
+// a pointer to slice, otherwise a single line is decoded).
+func (n *NDJSON) Decoder(r io.Reader) codec.Decoder {
+	dec := json.NewDecoder(r)
+	return codec.DecoderFunc(func(v interface{}) error {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+			return dec.Decode(v)
+		}
+		slice := rv.Elem()
+		for {
+			item := reflect.New(slice.Type().Elem())
+			if err := dec.Decode(item.Interface()); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+	})
+}
+
+// StreamDecoder creates NDJSON decoder that decodes a single line per call (and
+// returns io.EOF at the end of the stream).
+func (n *NDJSON) StreamDecoder(r io.Reader) codec.Decoder { return json.NewDecoder(r) }
+
+// MimeType returns the mime type of this codec (which is application/x-ndjson).
+func (n *NDJSON) MimeType() string { return DataTypeNDJSON }
+
+func init() {
+	// codec is stateless, so the same instance can always be returned
+	driver.Register(DataTypeNDJSON, func(string) codec.Codec { return global })
+}
@@ -0,0 +1,78 @@
+// Package yaml provides YAML codec (registered in the global codec registry on
+// import).
+package yaml
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/codec/driver"
+	"gopkg.in/yaml.v3"
+)
+
+// DataTypeYAML contains YAML codec data type.
+const DataTypeYAML = "application/yaml"
+
+var global = &YAML{}
+
+// YAML codec (struct fields are named by "json" tags, so the same models can be
+// used with JSON codec). Values are converted through JSON, so they are encoded
+// and decoded as by JSON codec.
+type YAML struct{}
+
+// Encoder creates YAML encoder (every encoded value is written as a separate
+// document).
+func (y *YAML) Encoder(w io.Writer) codec.Encoder {
+	return codec.EncoderFunc(func(v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		// JSON is valid YAML, so the document keeps the order of the fields
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		blockStyle(&doc)
+		enc := yaml.NewEncoder(w)
+		if err := enc.Encode(&doc); err != nil {
+			return err
+		}
+		// flush the document
+		return enc.Close()
+	})
+}
+
+// Decoder creates YAML decoder.
+func (y *YAML) Decoder(r io.Reader) codec.Decoder {
+	dec := yaml.NewDecoder(r)
+	return codec.DecoderFunc(func(v interface{}) error {
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, v)
+	})
+}
+
+// MimeType returns the mime type of this codec (which is application/yaml).
+func (y *YAML) MimeType() string { return DataTypeYAML }
+
+// blockStyle resets the style of the nodes parsed from JSON (flow collections and
+// quoted strings), so the encoder writes regular YAML.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func init() {
+	// codec is stateless, so the same instance can always be returned
+	driver.Register(DataTypeYAML, func(string) codec.Codec { return global })
+}
@@ -0,0 +1,324 @@
+package lite
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/codec/driver"
+	"github.com/tiny-go/errors"
+	mw "github.com/tiny-go/middleware"
+)
+
+// Codecs is a set of accepted request and response codecs. Nil registry means
+// that codecs of the enclosing controller, module or handler are used.
+type Codecs struct {
+	// Request contains codecs used to decode request body (by Content-Type).
+	Request codec.Registry
+	// Response contains codecs used to encode response body (by Accept).
+	Response codec.Registry
+}
+
+// CodecsProvider can be implemented by the module or controller in order to
+// declare its own set of codecs (nil means codecs of the parent are used).
+type CodecsProvider interface {
+	Codecs() *Codecs
+}
+
+// with returns the set of codecs overridden by codecs of provided module or
+// controller (if it implements CodecsProvider).
+func (c Codecs) with(v interface{}) Codecs {
+	provider, ok := v.(CodecsProvider)
+	if !ok {
+		return c
+	}
+	if own := provider.Codecs(); own != nil {
+		if own.Request != nil {
+			c.Request = own.Request
+		}
+		if own.Response != nil {
+			c.Response = own.Response
+		}
+	}
+	return c
+}
+
+// CodecList is a codec registry containing an explicit list of codecs, the first
+// one is used by default (if media type is not specified).
+type CodecList []codec.Codec
+
+// Lookup returns the codec by media type (parameters are ignored) or nil.
+func (cl CodecList) Lookup(mimeType string) codec.Codec {
+	if len(cl) == 0 {
+		return nil
+	}
+	if mimeType == "" || mimeType == "*/*" {
+		return cl[0]
+	}
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+	for _, c := range cl {
+		if c.MimeType() == mimeType {
+			return c
+		}
+	}
+	return nil
+}
+
+// codecsMiddleware returns a middleware that selects request codec by Content-Type
+// (decoding responds with 415 if it is not supported) and response codec by Accept header
+// using content negotiation (responding with 406 if it cannot be satisfied). If a
+// header is not provided the default codec is used (or the one selected for the
+// other direction).
+func codecsMiddleware(codecs Codecs) mw.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// response depends on Accept header (even if it is an error)
+			w.Header().Add("Vary", "Accept")
+
+			contentType, accept := r.Header.Get("Content-Type"), strings.Join(r.Header.Values("Accept"), ",")
+			reqCodec := lookup(codecs.Request, contentType)
+			var resCodec codec.Codec
+			if accept == "" {
+				if resCodec = lookup(codecs.Response, ""); resCodec == nil {
+					resCodec = reqCodec
+				}
+			} else {
+				resCodec = negotiate(codecs.Response, accept)
+			}
+			if resCodec == nil {
+				RenderError(w, r, errors.NewStatusError(http.StatusNotAcceptable,
+					fmt.Errorf("not acceptable: %q", accept)))
+				return
+			}
+			switch {
+			case reqCodec == nil && contentType != "":
+				// the body can still be read as Upload or Form (decoding it with the
+				// codec responds with 415)
+				reqCodec = &unsupportedCodec{contentType}
+			case reqCodec == nil:
+				reqCodec = resCodec
+			}
+			serveWithCodecs(w, r, reqCodec, resCodec, next)
+		})
+	}
+}
+
+// serveWithCodecs calls next handler with provided codecs in the request context.
+// Middleware package does not export its context keys, so the codecs are put into
+// the context by its own codec middleware, which looks them up by placeholder
+// headers of a copy of the request.
+func serveWithCodecs(w http.ResponseWriter, r *http.Request, reqCodec, resCodec codec.Codec, next http.Handler) {
+	probe := r.WithContext(r.Context())
+	probe.Header = http.Header{"Content-Type": {"request"}, "Accept": {"response"}}
+	mw.Codec(nil, negotiated{"request": reqCodec, "response": resCodec})(
+		http.HandlerFunc(func(w http.ResponseWriter, probe *http.Request) {
+			next.ServeHTTP(w, r.WithContext(probe.Context()))
+		}),
+	).ServeHTTP(w, probe)
+}
+
+// negotiated is a registry of already negotiated codecs by placeholder headers.
+type negotiated map[string]codec.Codec
+
+// Lookup implements codec.Registry.
+func (n negotiated) Lookup(mimeType string) codec.Codec { return n[mimeType] }
+
+// mediaRange is a single element of Accept header.
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// specificity returns the precedence of the media range (exact media types take
+// precedence over "type/*" which takes precedence over "*/*").
+func (mr mediaRange) specificity() int {
+	switch {
+	case mr.typ == "*":
+		return 0
+	case mr.subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+// match reports whether media type matches the range.
+func (mr mediaRange) match(mimeType string) bool {
+	typ, subtype := splitMediaType(mimeType)
+	return (mr.typ == "*" || mr.typ == typ) && (mr.subtype == "*" || mr.subtype == subtype)
+}
+
+// parseAccept parses Accept header (RFC 7231, section 5.3.2) and returns media
+// ranges ordered by quality and specificity (malformed elements are skipped).
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		mr := mediaRange{q: 1}
+		if mr.typ, mr.subtype = splitMediaType(mediaType); mr.subtype == "" || mr.typ == "*" && mr.subtype != "*" {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if mr.q, err = strconv.ParseFloat(q, 64); err != nil || mr.q < 0 || mr.q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mr)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// negotiate selects the response codec from the registry that satisfies Accept
+// header best. Structured syntax suffixes (for instance "application/vnd.api+json")
+// are served by the codec of the base media type ("application/json"). Ranges with
+// zero quality exclude the media type.
+func negotiate(registry codec.Registry, accept string) codec.Codec {
+	if registry == nil {
+		return nil
+	}
+	ranges := parseAccept(accept)
+	// acceptable reports whether the media type has not been excluded with "q=0"
+	// by the most specific matching range
+	acceptable := func(mimeType string) bool {
+		best := -1
+		var q float64
+		for _, mr := range ranges {
+			if mr.match(mimeType) && mr.specificity() > best {
+				best, q = mr.specificity(), mr.q
+			}
+		}
+		return best >= 0 && q > 0
+	}
+	for _, mr := range ranges {
+		if mr.q == 0 {
+			continue
+		}
+		switch mr.specificity() {
+		case 2:
+			mimeType := mr.typ + "/" + mr.subtype
+			if c := registry.Lookup(mimeType); c != nil {
+				return c
+			}
+			// structured syntax suffix
+			if idx := strings.LastIndex(mr.subtype, "+"); idx >= 0 {
+				if c := registry.Lookup(mr.typ + "/" + mr.subtype[idx+1:]); c != nil {
+					return &suffixed{c, mimeType}
+				}
+			}
+		default:
+			for _, c := range listCodecs(registry) {
+				if mr.match(c.MimeType()) && acceptable(c.MimeType()) {
+					return c
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MediaTypeLister can be implemented by the codec registry in order to list the
+// media types of its codecs (wildcard media ranges of Accept header are matched
+// against them).
+type MediaTypeLister interface {
+	MediaTypes() []string
+}
+
+// listCodecs returns the codecs of the registry in order to match wildcard media
+// ranges. Registries other than lists provide the default codec followed by the
+// codecs of their media types (in alphabetical order).
+func listCodecs(registry codec.Registry) []codec.Codec {
+	switch list := registry.(type) {
+	case CodecList:
+		return list
+	case driver.DummyRegistry:
+		return list
+	}
+	var list []codec.Codec
+	seen := make(map[string]bool)
+	for _, mimeType := range append([]string{""}, mediaTypes(registry)...) {
+		if c := registry.Lookup(mimeType); c != nil && !seen[c.MimeType()] {
+			seen[c.MimeType()] = true
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// mediaTypes returns the media types of the registry if it is able to list them
+// (MediaTypeLister or driver.SmartRegistry, for instance driver.Global).
+func mediaTypes(registry codec.Registry) []string {
+	var list []string
+	switch r := registry.(type) {
+	case MediaTypeLister:
+		list = append(list, r.MediaTypes()...)
+	case *driver.SmartRegistry:
+		// SmartRegistry does not export its media types, so they are read from the
+		// keys of its codecs map (under its own lock)
+		r.Lock()
+		defer r.Unlock()
+		codecs := reflect.ValueOf(r).Elem().FieldByName("codecs")
+		if codecs.Kind() != reflect.Map || codecs.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range codecs.MapKeys() {
+			// default codec is registered as "" and "*/*"
+			if mimeType := key.String(); mimeType != "" && mimeType != "*/*" {
+				list = append(list, mimeType)
+			}
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// splitMediaType splits media type into type and subtype (lower case).
+func splitMediaType(mediaType string) (string, string) {
+	mediaType = strings.ToLower(mediaType)
+	if idx := strings.Index(mediaType, "/"); idx >= 0 {
+		return mediaType[:idx], mediaType[idx+1:]
+	}
+	return mediaType, ""
+}
+
+// suffixed is a codec of the base media type serving media type with structured
+// syntax suffix (it reports requested media type as its own).
+type suffixed struct {
+	codec.Codec
+	mimeType string
+}
+
+// MimeType returns requested media type.
+func (s *suffixed) MimeType() string { return s.mimeType }
+
+// lookup searches for the codec in the registry (if available), media types with
+// structured syntax suffix are decoded by the codec of the base media type.
+func lookup(registry codec.Registry, mimeType string) codec.Codec {
+	if registry == nil {
+		return nil
+	}
+	if c := registry.Lookup(mimeType); c != nil {
+		return c
+	}
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		typ, subtype := splitMediaType(mediaType)
+		if idx := strings.LastIndex(subtype, "+"); idx >= 0 {
+			return registry.Lookup(typ + "/" + subtype[idx+1:])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package lite
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/codec/driver"
+	"github.com/tiny-go/codec/driver/json"
+	"github.com/tiny-go/codec/driver/xml"
+	"github.com/tiny-go/lite/codec/cbor"
+	"github.com/tiny-go/lite/codec/msgpack"
+	"github.com/tiny-go/lite/codec/yaml"
+	mw "github.com/tiny-go/middleware"
+)
+
+func Test_Codecs(t *testing.T) {
+	t.Run("Given handler, module and controller with own codecs", func(t *testing.T) {
+		driver.Default("application/json")
+		module := NewBaseModule()
+		module.SetCodecs(&Codecs{Request: CodecList{&json.JSON{}}, Response: CodecList{&json.JSON{}}})
+		module.Register("public", newPassController())
+		module.Register("internal", &mockCodecsController{
+			newPassController(),
+			&Codecs{Response: CodecList{&xml.XML{}, &json.JSON{}}},
+		})
+		handler := NewHandler(WithLogger(DiscardLogger))
+		handler.Use("module", module)
+		handler.Use("global", func() Module {
+			global := NewBaseModule()
+			global.Register("user", newPassController())
+			return global
+		}())
+		cases := []struct {
+			title       string
+			method      string
+			uri         string
+			contentType string
+			accept      string
+			code        int
+			resType     string
+		}{
+			{"module codecs should be used by default", http.MethodGet, "/module/public/1", "", "", http.StatusOK, "application/json"},
+			{"unsupported Accept should be rejected", http.MethodGet, "/module/public/1", "", "application/xml", http.StatusNotAcceptable, ""},
+			{"unsupported Content-Type should be rejected", http.MethodPost, "/module/public/1", "application/xml", "", http.StatusUnsupportedMediaType, ""},
+			{"Content-Type parameters should be ignored", http.MethodPost, "/module/public/1", "application/json; charset=utf-8", "", http.StatusCreated, "application/json"},
+			{"controller codecs should override module codecs", http.MethodGet, "/module/internal/1", "", "application/xml", http.StatusOK, "application/xml"},
+			{"controller default codec should be used", http.MethodGet, "/module/internal/1", "", "", http.StatusOK, "application/xml"},
+			{"controller should inherit module request codecs", http.MethodPost, "/module/internal/1", "application/xml", "", http.StatusUnsupportedMediaType, ""},
+			{"global codecs should be used by handler", http.MethodGet, "/global/user/1", "", "application/xml", http.StatusOK, "application/xml"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.title, func(t *testing.T) {
+				r := httptest.NewRequest(tc.method, tc.uri, strings.NewReader("{}"))
+				if tc.contentType != "" {
+					r.Header.Set("Content-Type", tc.contentType)
+				}
+				if tc.accept != "" {
+					r.Header.Set("Accept", tc.accept)
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tc.code {
+					t.Errorf("status code was expected to be %d but got %d (%s)", tc.code, w.Code, w.Body)
+				}
+				if tc.resType != "" && w.Header().Get("Content-Type") != tc.resType {
+					t.Errorf("content type was expected to be %q but got %q", tc.resType, w.Header().Get("Content-Type"))
+				}
+			})
+		}
+	})
+	t.Run("Given explicit list of codecs", func(t *testing.T) {
+		list := CodecList{&json.JSON{}, &xml.XML{}}
+		t.Run("should lookup codecs by media type", func(t *testing.T) {
+			for mime, expected := range map[string]string{
+				"":                               "application/json",
+				"*/*":                            "application/json",
+				"application/xml":                "application/xml",
+				"application/xml; charset=utf-8": "application/xml",
+				"text/plain":                     "",
+			} {
+				c := list.Lookup(mime)
+				if expected == "" && c != nil || expected != "" && (c == nil || c.MimeType() != expected) {
+					t.Errorf("unexpected codec %v for %q", c, mime)
+				}
+			}
+			if (CodecList{}).Lookup("") != nil {
+				t.Error("empty list should not return any codec")
+			}
+		})
+	})
+	t.Run("Given Accept header", func(t *testing.T) {
+		list := CodecList{&json.JSON{}, &xml.XML{}}
+		cases := []struct {
+			accept   string
+			expected string
+		}{
+			{"application/xml", "application/xml"},
+			{"application/json;q=0.1, application/xml;q=0.2", "application/xml"},
+			{"*/*;q=0.1, application/xml;q=0.1", "application/xml"},
+			{"*/*", "application/json"},
+			{"application/*;q=0.5, application/json;q=0", "application/xml"},
+			{"text/*", ""},
+			{"application/problem+xml", "application/problem+xml"},
+			{"application/json;q=2, text/html, invalid", ""},
+		}
+		for _, tc := range cases {
+			t.Run("should select codec for "+tc.accept, func(t *testing.T) {
+				c := negotiate(list, tc.accept)
+				switch {
+				case tc.expected == "" && c != nil:
+					t.Errorf("no codec was expected but got %q", c.MimeType())
+				case tc.expected != "" && c == nil:
+					t.Errorf("codec %q was expected", tc.expected)
+				case c != nil && c.MimeType() != tc.expected:
+					t.Errorf("codec %q was expected to be %q", c.MimeType(), tc.expected)
+				}
+			})
+		}
+		t.Run("should match wildcards with codecs of global registry", func(t *testing.T) {
+			driver.Default("application/json")
+			for _, accept := range []string{
+				"application/*;q=0.5, application/json;q=0",
+				"*/*;q=0.5, application/json;q=0",
+			} {
+				if c := negotiate(driver.Global(), accept); c == nil || c.MimeType() != "application/cbor" {
+					t.Errorf("%q: the first non-excluded codec was expected but got %v", accept, c)
+				}
+			}
+			// codec with media type unknown to the package
+			if driver.Global().Lookup("text/x-lite-test") == nil {
+				driver.Register("text/x-lite-test", func(string) codec.Codec { return &mockTextCodec{} })
+			}
+			if c := negotiate(driver.Global(), "text/*, text/csv;q=0"); c == nil || c.MimeType() != "text/x-lite-test" {
+				t.Errorf("custom codec was expected but got %v", c)
+			}
+		})
+		t.Run("should match wildcards with media types of the registry", func(t *testing.T) {
+			registry := &mockListingRegistry{DummyRegistry: driver.DummyRegistry{&json.JSON{}, &xml.XML{}}}
+			if c := negotiate(registry, "*/*, application/json;q=0"); c == nil || c.MimeType() != "application/xml" {
+				t.Errorf("XML codec was expected but got %v", c)
+			}
+		})
+	})
+}
+
+func Test_PayloadCodecs(t *testing.T) {
+	t.Run("Given handler with binary and text payload codecs", func(t *testing.T) {
+		payloadCodecs := CodecList{&msgpack.Msgpack{}, &cbor.CBOR{}, &yaml.YAML{}}
+		typed := &mockTypedController{Controller: mw.NewBaseController()}
+		module := NewBaseModule()
+		module.Register("pass", newPassController())
+		module.Register("typed", NewTypedController(typed, TypedPost(typed.Post)))
+		handler := NewHandler(WithLogger(DiscardLogger), WithCodecs(payloadCodecs))
+		handler.Map("!")
+		if err := handler.Use("test", module); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		for _, c := range payloadCodecs {
+			t.Run("should round-trip "+c.MimeType()+" payload with regular controller", func(t *testing.T) {
+				body := &bytes.Buffer{}
+				if err := c.Encoder(body).Encode(map[string]interface{}{"baz": "qux"}); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				r := httptest.NewRequest(http.MethodPost, "/test/pass/1", body)
+				r.Header.Set("Content-Type", c.MimeType())
+				r.Header.Set("Accept", c.MimeType())
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != http.StatusCreated || w.Header().Get("Content-Type") != c.MimeType() {
+					t.Fatalf("unexpected response %d %q", w.Code, w.Header().Get("Content-Type"))
+				}
+				var data map[string]interface{}
+				if err := c.Decoder(w.Body).Decode(&data); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if data["baz"] != "qux" {
+					t.Errorf("%v was expected to contain decoded payload", data)
+				}
+			})
+			t.Run("should round-trip "+c.MimeType()+" payload with typed controller", func(t *testing.T) {
+				body := &bytes.Buffer{}
+				if err := c.Encoder(body).Encode(&mockTypedInput{Foo: "bar"}); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				r := httptest.NewRequest(http.MethodPost, "/test/typed/1", body)
+				r.Header.Set("Content-Type", c.MimeType())
+				r.Header.Set("Accept", c.MimeType())
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != http.StatusCreated {
+					t.Fatalf("unexpected status code %d (%s)", w.Code, w.Body)
+				}
+				data := &mockTypedInput{}
+				if err := c.Decoder(w.Body).Decode(data); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if data.Foo != "bar!" {
+					t.Errorf("%q was expected to be %q", data.Foo, "bar!")
+				}
+			})
+			t.Run("should name "+c.MimeType()+" fields by json tags", func(t *testing.T) {
+				body := &bytes.Buffer{}
+				if err := c.Encoder(body).Encode(&mockCamelModel{FooBar: "baz"}); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				encoded := body.Bytes()
+				var data map[string]interface{}
+				if err := c.Decoder(bytes.NewReader(encoded)).Decode(&data); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if data["fooBar"] != "baz" {
+					t.Errorf("%v was expected to contain \"fooBar\" key", data)
+				}
+				model := &mockCamelModel{}
+				if err := c.Decoder(bytes.NewReader(encoded)).Decode(model); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if model.FooBar != "baz" {
+					t.Errorf("%q was expected to be %q", model.FooBar, "baz")
+				}
+			})
+		}
+	})
+}
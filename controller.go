@@ -7,3 +7,28 @@ type Controller interface {
 	mw.Controller
 	Init() error
 }
+
+// adapter is implemented by controllers wrapping another controller (for instance
+// TypedController) and providing only a part of its action methods.
+type adapter interface {
+	// unwrap should return the wrapped controller.
+	unwrap() Controller
+	// implements should report whether the action (method name) is provided.
+	implements(action string) bool
+}
+
+// unwrap returns the controller wrapped by adapter or the controller itself.
+func unwrap(resource Controller) Controller {
+	if a, ok := resource.(adapter); ok {
+		return a.unwrap()
+	}
+	return resource
+}
+
+// implements reports whether the action (method name) is provided by the controller.
+func implements(resource Controller, action string) bool {
+	if a, ok := resource.(adapter); ok {
+		return a.implements(action)
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package lite
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ModuleInfo contains metadata of the module.
+type ModuleInfo struct {
+	// Name of the module (its alias is used if empty).
+	Name string `json:"name" xml:"Name"`
+	// Version of the module.
+	Version string `json:"version,omitempty" xml:"Version,omitempty"`
+	// DependsOn contains aliases of the modules required by the module (they are
+	// used by the handler first).
+	DependsOn []string `json:"dependsOn,omitempty" xml:"DependsOn,omitempty"`
+}
+
+// ModuleInfoProvider can be implemented by the module in order to provide its
+// metadata (name, version and dependencies).
+type ModuleInfoProvider interface {
+	Info() ModuleInfo
+}
+
+// ModuleGraph is the resolved graph of registered modules.
+type ModuleGraph struct {
+	// Order contains aliases of the modules in order of initialization (the
+	// dependencies go first).
+	Order []string `json:"order" xml:"Order"`
+	// Modules contains metadata of the modules by aliases.
+	Modules map[string]ModuleInfo `json:"modules" xml:"-"`
+	// modules are the resolved modules by aliases
+	modules map[string]Module
+}
+
+// DependencyError is returned if the module depends on unregistered module.
+type DependencyError struct {
+	Module     string
+	Dependency string
+}
+
+// Error implements error interface.
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("module %q depends on unknown module %q", e.Module, e.Dependency)
+}
+
+// CycleError is returned if module dependencies form a cycle.
+type CycleError struct {
+	// Cycle contains module aliases (the first one is repeated at the end).
+	Cycle []string
+}
+
+// Error implements error interface.
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("module dependency cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// ResolveModules sorts modules of the global registry by their dependencies (the
+// modules without dependencies keep the order of Modules func). Unknown dependencies
+// are reported as DependencyError and cycles as CycleError.
+func ResolveModules() (*ModuleGraph, error) {
+	graph := &ModuleGraph{Modules: make(map[string]ModuleInfo), modules: make(map[string]Module)}
+	var aliases []string
+	Modules(func(alias string, module Module) bool {
+		aliases = append(aliases, alias)
+		graph.Modules[alias], graph.modules[alias] = moduleInfo(alias, module), module
+		return true
+	})
+	// check all the dependencies first
+	var errs Errors
+	for _, alias := range aliases {
+		for _, dep := range graph.Modules[alias].DependsOn {
+			if _, ok := graph.Modules[dep]; !ok {
+				errs = append(errs, &DependencyError{alias, dep})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	// depth-first search adding the module after its dependencies
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(alias string) error
+	visit = func(alias string) error {
+		switch state[alias] {
+		case visited:
+			return nil
+		case visiting:
+			for i, curr := range path {
+				if curr == alias {
+					return &CycleError{append(append([]string{}, path[i:]...), alias)}
+				}
+			}
+		}
+		state[alias] = visiting
+		path = append(path, alias)
+		for _, dep := range graph.Modules[alias].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[alias] = visited
+		graph.Order = append(graph.Order, alias)
+		return nil
+	}
+	for _, alias := range aliases {
+		if err := visit(alias); err != nil {
+			return nil, err
+		}
+	}
+	return graph, nil
+}
+
+// UseModules resolves the modules of the global registry and uses them by the
+// handler in order of their dependencies (it stops if the module cannot be used,
+// so the modules depending on it are not used either).
+func UseModules(h Handler) (*ModuleGraph, error) {
+	graph, err := ResolveModules()
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range graph.Order {
+		if err := h.Use(alias, graph.modules[alias]); err != nil {
+			return graph, err
+		}
+	}
+	return graph, nil
+}
+
+// ModuleGraphHandler returns an HTTP handler that sends the resolved graph of the
+// modules using response codec (it can be mounted with handler.HandleMethod, for
+// instance as "/_modules").
+func ModuleGraphHandler(graph *ModuleGraph) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		send(w, r, http.StatusOK, graph)
+	})
+}
+
+// moduleInfo returns metadata of the module (name defaults to the alias).
+func moduleInfo(alias string, module Module) ModuleInfo {
+	var info ModuleInfo
+	if provider, ok := module.(ModuleInfoProvider); ok {
+		info = provider.Info()
+	}
+	if info.Name == "" {
+		info.Name = alias
+	}
+	return info
+}
@@ -0,0 +1,98 @@
+package lite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+)
+
+func Test_ResolveModules(t *testing.T) {
+	module := func(version string, deps ...string) *BaseModule {
+		m := NewBaseModule()
+		m.Register("items", newPassController())
+		m.SetInfo(ModuleInfo{Version: version, DependsOn: deps})
+		return m
+	}
+	t.Run("Given modules depending on each other", func(t *testing.T) {
+		driver.Default("application/json")
+		defer func() { modules = make(map[string]Module) }()
+		Register("billing", module("1.0", "auth", "users"))
+		Register("users", module("1.1", "auth"))
+		Register("search", module(""))
+		Register("auth", module("2.0"))
+		t.Run("should sort modules by dependencies", func(t *testing.T) {
+			graph, err := ResolveModules()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := []string{"auth", "users", "billing", "search"}; !reflect.DeepEqual(graph.Order, expected) {
+				t.Errorf("order %v was expected to be %v", graph.Order, expected)
+			}
+			expected := ModuleInfo{Name: "billing", Version: "1.0", DependsOn: []string{"auth", "users"}}
+			if !reflect.DeepEqual(graph.Modules["billing"], expected) {
+				t.Errorf("module info %v was expected to be %v", graph.Modules["billing"], expected)
+			}
+		})
+		t.Run("should use the modules in order of dependencies", func(t *testing.T) {
+			handler := NewHandler(WithLogger(DiscardLogger))
+			if _, err := UseModules(handler); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var order []string
+			for _, route := range handler.Routes() {
+				if len(order) == 0 || order[len(order)-1] != route.Module {
+					order = append(order, route.Module)
+				}
+			}
+			if expected := []string{"auth", "users", "billing", "search"}; !reflect.DeepEqual(order, expected) {
+				t.Errorf("modules were used in order %v instead of %v", order, expected)
+			}
+		})
+		t.Run("should expose the graph", func(t *testing.T) {
+			graph, _ := ResolveModules()
+			handler := NewHandler(WithLogger(DiscardLogger))
+			handler.HandleMethod(http.MethodGet, "/_modules", ModuleGraphHandler(graph))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_modules", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusOK)
+			}
+			if body := w.Body.String(); !strings.HasPrefix(body, `{"order":["auth","users","billing","search"],"modules":{"auth":{"name":"auth","version":"2.0"}`) {
+				t.Errorf("unexpected response %q", body)
+			}
+		})
+	})
+	t.Run("Given modules with unknown dependencies", func(t *testing.T) {
+		defer func() { modules = make(map[string]Module) }()
+		Register("billing", module("", "auth", "users"))
+		Register("users", module("", "accounts"))
+		t.Run("should report all missing dependencies", func(t *testing.T) {
+			_, err := ResolveModules()
+			expected := Errors{&DependencyError{"billing", "auth"}, &DependencyError{"users", "accounts"}}
+			if !reflect.DeepEqual(err, expected) {
+				t.Errorf("error %v was expected to be %v", err, expected)
+			}
+		})
+	})
+	t.Run("Given modules with cyclic dependencies", func(t *testing.T) {
+		defer func() { modules = make(map[string]Module) }()
+		Register("search", module(""))
+		Register("billing", module("", "users"))
+		Register("users", module("", "auth"))
+		Register("auth", module("", "billing"))
+		t.Run("should report the cycle", func(t *testing.T) {
+			_, err := ResolveModules()
+			if !reflect.DeepEqual(err, &CycleError{[]string{"billing", "users", "auth", "billing"}}) {
+				t.Errorf("unexpected error %v", err)
+			}
+			if err.Error() != "module dependency cycle: billing -> users -> auth -> billing" {
+				t.Errorf("unexpected error message %q", err.Error())
+			}
+		})
+	})
+}
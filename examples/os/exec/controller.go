@@ -1,6 +1,7 @@
 package exec
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -47,6 +48,6 @@ func (c *Controller) Get(_ context.Context, command string) (interface{}, error)
 	if err != nil {
 		return err, nil
 	}
-
-	return out, nil
+	// send the output as is (instead of encoding it with the codec)
+	return &lite.Blob{ContentType: "text/plain; charset=utf-8", Inline: true, Content: bytes.NewReader(out)}, nil
 }
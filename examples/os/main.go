@@ -38,11 +38,10 @@ func main() {
 		"sensors",
 		"whoami",
 	})
-	// register modules
-	lite.Modules(func(alias string, module lite.Module) bool {
-		handler.Use(alias, module)
-		return true
-	})
+	// register modules (in order of their dependencies)
+	if _, err := lite.UseModules(handler); err != nil {
+		log.Fatal(err)
+	}
 	// start HTTP server
 	log.Fatal(http.ListenAndServe(":8080", handler))
 }
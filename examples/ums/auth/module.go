@@ -10,6 +10,12 @@ import (
 // have only one instance of the module (keep it as a rule using global registry).
 func init() {
 	module := lite.NewBaseModule()
-	module.Register("", &user.Controller{BaseController: mw.NewBaseController()})
+	controller := &user.Controller{BaseController: mw.NewBaseController()}
+	// adapt type-safe actions to the regular controller interfaces
+	module.Register("", lite.NewTypedController(
+		controller,
+		lite.TypedPostAll(controller.PostAll),
+		lite.TypedPatchAll(controller.PatchAll),
+	))
 	lite.Register("auth", module)
 }
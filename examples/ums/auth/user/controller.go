@@ -11,8 +11,8 @@ import (
 
 var (
 	// compile-time type check (Controller should implement both interfaces)
-	_ lite.PluralPoster  = &Controller{}
-	_ lite.PluralPatcher = &Controller{}
+	_ lite.TypedPluralPoster[*Auth, *Auth]  = &Controller{}
+	_ lite.TypedPluralPatcher[*Auth, *Auth] = &Controller{}
 )
 
 // Controller is responsible for user AUTH operations.
@@ -28,19 +28,11 @@ type Controller struct {
 func (c *Controller) Init() error { return nil }
 
 // PostAll handles user login request.
-func (c *Controller) PostAll(_ context.Context, cf func(interface{}) error) (interface{}, error) {
-	auth := new(Auth)
-	if err := cf(auth); err != nil {
-		return nil, err
-	}
+func (c *Controller) PostAll(_ context.Context, auth *Auth) (*Auth, error) {
 	return auth, auth.Login(c.Config, c.Users)
 }
 
 // PatchAll handles user token refresh request.
-func (c *Controller) PatchAll(_ context.Context, _ url.Values, cf func(interface{}) error) (interface{}, error) {
-	auth := new(Auth)
-	if err := cf(auth); err != nil {
-		return nil, err
-	}
+func (c *Controller) PatchAll(_ context.Context, _ url.Values, auth *Auth) (*Auth, error) {
 	return auth, auth.RefreshToken()
 }
@@ -33,11 +33,10 @@ func main() {
 		"admin@test.com": "admin",
 		"user@test.com":  "user",
 	})
-	// register modules
-	lite.Modules(func(alias string, module lite.Module) bool {
-		handler.Use(alias, module)
-		return true
-	})
+	// register modules (in order of their dependencies)
+	if _, err := lite.UseModules(handler); err != nil {
+		log.Fatal(err)
+	}
 	// start HTTP server
 	log.Fatal(http.ListenAndServe(":8080", handler))
 }
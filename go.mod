@@ -1,13 +1,22 @@
 module github.com/tiny-go/lite
 
-go 1.13
+go 1.18
 
 require (
 	github.com/codegangsta/inject v0.0.0-20150114235600-33e0aa1cb7c0
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/fxamacker/cbor/v2 v2.5.0
 	github.com/gorilla/mux v1.7.3
 	github.com/tiny-go/codec v1.0.0
 	github.com/tiny-go/config v1.0.0
 	github.com/tiny-go/errors v1.0.0
 	github.com/tiny-go/middleware v1.0.0
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/tiny-go/timap v1.0.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 )
@@ -0,0 +1,108 @@
+package lite
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+// group contains the routes of a single module (or custom routes if module is nil),
+// route table of the handler is built from the groups in order of registration.
+type group struct {
+	alias  string
+	module Module
+	// entries are the routes of the group with their handlers
+	entries []entry
+	// controllers are initialized controllers by their base paths
+	controllers controllers
+	// prev is the previous group of the module (its controllers should not be
+	// initialized again when the module is rebuilt)
+	prev *group
+	// modules are the module and nested modules watched for changes
+	modules []Module
+	// cancel removes the listeners of the module changes (it should not be called
+	// under the lock of the handler)
+	cancel []func()
+	// changed is set once the modules of the group have been changed
+	changed int32
+	// started are the controllers initialized while the group was mounted
+	started []started
+	// kept are the controllers of the previous group mounted as they are
+	kept []started
+	// errs are the errors of the controllers that could not be mounted
+	errs Errors
+}
+
+// entry is a route with its handler.
+type entry struct {
+	route   Route
+	handler http.Handler
+}
+
+// add adds the route to the group.
+func (g *group) add(route Route, handler http.Handler) {
+	g.entries = append(g.entries, entry{route, handler})
+}
+
+// watch adds the module to the list of watched modules.
+func (g *group) watch(module Module) {
+	g.modules = append(g.modules, module)
+}
+
+// unwatch removes all the listeners of the module changes.
+func (g *group) unwatch() {
+	for _, cancel := range g.cancel {
+		cancel()
+	}
+	g.cancel = nil
+}
+
+// fail adds the error of the controller (if any) to the list of errors.
+func (g *group) fail(err error) {
+	if err != nil {
+		g.errs = append(g.errs, err)
+	}
+}
+
+// rollback shuts down the controllers initialized while the group was mounted
+// and returns the errors of the failed controllers (and failed shutdown hooks).
+func (g *group) rollback() error {
+	errs := g.errs
+	if err := shutdownAll(context.Background(), g.started); err != nil {
+		errs = append(errs, err.(Errors)...)
+	}
+	g.started = nil
+	return errs
+}
+
+// running returns all the initialized controllers of the group.
+func (g *group) running() []started {
+	return append(append([]started{}, g.kept...), g.started...)
+}
+
+// dropped returns the controllers of the group that are not kept by the next one.
+func (g *group) dropped(next *group) []started {
+	var list []started
+	for _, curr := range g.running() {
+		if !contains(next.kept, curr) {
+			list = append(list, curr)
+		}
+	}
+	return list
+}
+
+// controllers is a set of controllers by their base paths.
+type controllers map[string]Controller
+
+// has reports whether the same controller is registered with provided base path.
+func (cs controllers) has(basePath string, resource Controller) bool {
+	curr, ok := cs[basePath]
+	if !ok {
+		return false
+	}
+	curr, resource = unwrap(curr), unwrap(resource)
+	if reflect.TypeOf(curr) != reflect.TypeOf(resource) || !reflect.TypeOf(curr).Comparable() {
+		return false
+	}
+	return curr == resource
+}
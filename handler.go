@@ -1,260 +1,647 @@
 package lite
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/codegangsta/inject"
 	"github.com/gorilla/mux"
 	"github.com/tiny-go/codec/driver"
-	"github.com/tiny-go/errors"
 	mw "github.com/tiny-go/middleware"
 )
 
-// global handler for http errors
-var errFn = http.Error
-
 // Handler interface describes HTTP API handler.
 type Handler interface {
 	http.Handler
 	Use(string, Module) error
 	Map(interface{}) inject.TypeMapper
-	// TODO: allow registering custom routes
-	// HandleFunc(string, http.HandlerFunc)
+	// Handle registers custom handler for the given path (any HTTP method).
+	Handle(pattern string, handler http.Handler)
+	// HandleFunc registers custom handler func for the given path (any HTTP method).
+	HandleFunc(pattern string, f func(http.ResponseWriter, *http.Request))
+	// HandleMethod registers custom handler for the given HTTP method and path.
+	HandleMethod(method, pattern string, handler http.Handler)
+	// SetErrorRenderer replaces the renderer used to send errors to the client.
+	SetErrorRenderer(ErrorRenderer)
+	// SetLogger replaces the logger used for route registration, failures and
+	// access logs.
+	SetLogger(Logger)
+	// Routes returns the list of registered routes.
+	Routes() []Route
+	// Remove unregisters the module by alias and drops its routes.
+	Remove(alias string) error
+	// Shutdown waits for in-flight requests and shuts down the controllers.
+	Shutdown(ctx context.Context) error
 }
 
 // handler combines all registered modules (with their controllers) to a single API.
 type handler struct {
-	*mux.Router
 	inject.Injector
-	// modules is a local registry which is needed for alias/module unique check
-	modules map[string]Module
+	// router serves the current route table (it is replaced as a whole when the
+	// routes are changed, so in-flight requests are served by the previous one)
+	router atomic.Value
+	// mu guards the groups of routes (modules and custom routes)
+	mu sync.Mutex
+	// groups contains the routes of used modules and custom routes in order of
+	// registration (it is also needed for alias/module unique check)
+	groups []*group
+	// started contains initialized controllers in order of initialization
+	started []started
+	// drain tracks in-flight requests (and rejects new ones on shutdown)
+	drain drain
+	// renderer is used to send errors to the client
+	renderer ErrorRenderer
+	// logger is used for route registration, failures and access logs
+	logger Logger
+	// logRequests enables access log
+	logRequests bool
+	// basePath is a path prefix of all the routes
+	basePath string
+	// codecs are request/response codecs (unless module or controller has own)
+	codecs Codecs
+	// decoding contains request body settings (unless module or controller has own)
+	decoding Decoding
+	// stack is the default middleware applied to every route first (it recovers
+	// from panics unless replaced)
+	stack []mw.Middleware
+	// middleware is applied to every route (after the default middleware)
+	middleware []mw.Middleware
+	// strictSlash reports whether trailing slash is significant
+	strictSlash bool
+	// redirectSlash makes the router redirect to the route with(out) trailing slash
+	redirectSlash bool
 }
 
-// NewHandler creates new HTTP handler.
-func NewHandler() Handler {
-	return &handler{
-		Router:   mux.NewRouter(),
+// NewHandler creates new HTTP handler configured with provided options.
+func NewHandler(opts ...Option) Handler {
+	h := &handler{
 		Injector: inject.New(),
-		modules:  make(map[string]Module)}
+		renderer: TextRenderer,
+		logger:   StdLogger(nil),
+		codecs:   Codecs{driver.Global(), driver.Global()},
+		// trailing slash is significant by default (as in gorilla/mux)
+		strictSlash: true,
+	}
+	h.stack = []mw.Middleware{h.recoverer}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.build()
+	return h
+}
+
+// Use registers the module with provided alias. If the module implements Notifier
+// its routes are rebuilt every time the controllers are registered or unregistered.
+// The module is used only if all its controllers are initialized, otherwise the
+// initialized ones are shut down and the errors of the failed ones are returned.
+func (h *handler) Use(alias string, module Module) error {
+	h.mu.Lock()
+	_, inUse := h.group(alias)
+	h.mu.Unlock()
+	if inUse != nil {
+		return fmt.Errorf("alias already in use %q", alias)
+	}
+	// controllers are initialized without the lock, so their Init may call the
+	// handler (for instance to add custom routes)
+	g, err := h.mountModule(alias, module, nil)
+	if err != nil {
+		return err
+	}
+	// modules lock themselves while the controllers are initialized, so they are
+	// never subscribed (or unsubscribed) under the lock of the handler
+	h.watch(g)
+
+	h.mu.Lock()
+	if _, inUse := h.group(alias); inUse != nil {
+		h.mu.Unlock()
+		// the alias has been taken while the module was being mounted
+		g.unwatch()
+		g.rollback()
+		return fmt.Errorf("alias already in use %q", alias)
+	}
+	h.started = append(h.started, g.started...)
+	h.groups = append(h.groups, g)
+	h.build()
+	h.mu.Unlock()
+
+	h.refresh(g)
+	return nil
+}
+
+// Remove unregisters the module by alias, drops its routes and calls shutdown
+// hooks of its controllers (requests that are already being served are not
+// interrupted).
+func (h *handler) Remove(alias string) error {
+	h.mu.Lock()
+	i, g := h.group(alias)
+	if g == nil {
+		h.mu.Unlock()
+		return fmt.Errorf("module not found %q", alias)
+	}
+	h.groups = append(h.groups[:i:i], h.groups[i+1:]...)
+	// controllers may have been shut down by Shutdown already
+	list := running(g.running(), h.started)
+	h.started = without(h.started, list)
+	h.build()
+	h.mu.Unlock()
+
+	g.unwatch()
+	h.logger.Info("module removed", "module", alias)
+	return shutdownAll(context.Background(), list)
 }
 
-// Use registers the module with provided alias.
-func (h *handler) Use(alias string, module Module) (err error) {
-	for key := range h.modules {
-		if key == alias {
-			return fmt.Errorf("alias already in use %q", alias)
+// group returns the group of the module by alias (and its index) or nil.
+func (h *handler) group(alias string) (int, *group) {
+	for i, g := range h.groups {
+		if g.module != nil && g.alias == alias {
+			return i, g
 		}
 	}
+	return -1, nil
+}
 
+// mountModule initializes the controllers of the module and collects their routes,
+// controllers of the previous group (if any) are not initialized again. If any
+// controller fails, the controllers initialized by this call are shut down and
+// the errors of all the failed ones are returned.
+func (h *handler) mountModule(alias string, module Module, prev *group) (*group, error) {
+	g := &group{alias: alias, module: module, controllers: make(map[string]Controller), prev: prev}
+	g.watch(module)
+	// module may accept its own codecs and decoding settings
+	cfg := routeConfig{h.codecs, h.decoding}.with(module)
 	module.Controllers(func(controllerPath string, resource Controller) bool {
-		// inject dependencies to the controllers
-		if err = h.Apply(resource); err != nil {
-			return false
+		g.fail(h.useController(g, path.Join("/", h.basePath, alias), cfg, nil, controllerPath, resource))
+		return true
+	})
+	if len(g.errs) > 0 {
+		return nil, g.rollback()
+	}
+	return g, nil
+}
+
+// rebuild mounts the routes of the module again (after its controllers have been
+// changed) and replaces the route table, the previous routes are kept on failure.
+// Controllers that are not mounted anymore are shut down.
+func (h *handler) rebuild(alias string) {
+	h.mu.Lock()
+	_, prev := h.group(alias)
+	h.mu.Unlock()
+	if prev == nil {
+		return
+	}
+	// new controllers are initialized without the lock (as in Use)
+	next, err := h.mountModule(alias, prev.module, prev)
+	if err != nil {
+		h.logger.Error("module rebuild failed", "module", alias, "error", err)
+		return
+	}
+	h.watch(next)
+
+	h.mu.Lock()
+	i, curr := h.group(alias)
+	if curr != prev {
+		h.mu.Unlock()
+		// the module has been removed or rebuilt in the meantime (the change may
+		// not be applied by the other rebuild, so it is retried)
+		next.unwatch()
+		next.rollback()
+		if curr != nil {
+			h.rebuild(alias)
 		}
-		// init current controller first and if failed return false to exit the loop
-		if err = resource.Init(); err != nil {
-			return false
+		return
+	}
+	dropped := running(prev.dropped(next), h.started)
+	h.started = append(without(h.started, dropped), next.started...)
+	h.groups[i] = next
+	h.build()
+	h.mu.Unlock()
+
+	prev.unwatch()
+	h.logger.Info("module rebuilt", "module", alias)
+	if err := shutdownAll(context.Background(), dropped); err != nil {
+		h.logger.Error("controller shutdown failed", "module", alias, "error", err)
+	}
+	h.refresh(next)
+}
+
+// refresh rebuilds the group if its modules have been changed before it was
+// added to the handler (the change could not be applied then).
+func (h *handler) refresh(g *group) {
+	if atomic.LoadInt32(&g.changed) != 0 {
+		h.rebuild(g.alias)
+	}
+}
+
+// watch subscribes to the changes of the modules of the group (it should not be
+// called under the lock of the handler).
+func (h *handler) watch(g *group) {
+	for _, module := range g.modules {
+		if notifier, ok := module.(Notifier); ok {
+			g.cancel = append(g.cancel, notifier.OnChange(func() {
+				atomic.StoreInt32(&g.changed, 1)
+				h.rebuild(g.alias)
+			}))
 		}
-		basePath := []string{"/", alias, controllerPath}
-		// list of available methods for current resource (required for OPTIONS request)
-		var allowedSingle = &Methods{}
-		var allowedPlural = &Methods{}
+	}
+}
 
-		// [GET] plural
-		if controller, ok := resource.(PluralGetter); ok {
-			h.Router.Handle(
-				path.Join(basePath...),
-				// apply default middleware (no need to close the body with mw.BodyClose)
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), GorillaParams).
-					// extract custom (user defined) middleware for HTTP method GET
-					Use(controller.Middleware(http.MethodGet)).
-					// set final handler
-					Then(getPlural(controller)),
-			).Methods(http.MethodGet)
-			// add bulk GET request to OPTIONS list
-			allowedPlural.Add(http.MethodGet)
-
-			log.Printf("[GET] %s\n", path.Join(basePath...))
+// build creates new router with the routes of all the groups and replaces the
+// current one.
+func (h *handler) build() {
+	router := mux.NewRouter()
+	router.StrictSlash(h.redirectSlash)
+	for _, g := range h.groups {
+		for _, e := range g.entries {
+			route := router.Handle(e.route.Path, e.handler)
+			if e.route.Method != "*" {
+				route.Methods(e.route.Method)
+			}
 		}
-		// [GET] single
-		if controller, ok := resource.(SingleGetter); ok {
-			h.Router.Handle(
-				path.Join(append(basePath, "{pk}")...),
-				// apply default middleware (no need to close the body with mw.BodyClose)
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), GorillaParams).
-					// extract custom (user defined) middleware for HTTP method GET
-					Use(controller.Middleware(http.MethodGet)).
-					// set final handler
-					Then(getSingle(controller)),
-			).Methods(http.MethodGet)
-			// add single GET request to OPTIONS list
-			allowedSingle.Add(http.MethodGet)
-
-			log.Printf("[GET] %s\n", path.Join(append(basePath, "{pk}")...))
+	}
+	h.router.Store(router)
+}
+
+// useController initializes the controller and mounts its routes (including nested
+// controllers) under provided path prefix (which contains primary keys of the parents).
+func (h *handler) useController(g *group, prefix string, cfg routeConfig, parentKeys []keyParam, controllerPath string, resource Controller) (err error) {
+	module := g.alias
+	basePath := path.Join(prefix, controllerPath)
+	// controllers that have already been initialized (if module is rebuilt) are
+	// mounted as they are
+	if g.prev != nil && g.prev.controllers.has(basePath, resource) {
+		for _, curr := range g.prev.running() {
+			if curr.path == basePath {
+				g.kept = append(g.kept, curr)
+			}
 		}
-		// [POST] plural
-		if controller, ok := resource.(PluralPoster); ok {
-			h.Router.Handle(
-				path.Join(basePath...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), mw.BodyClose, GorillaParams).
-					// extract custom (user defined) middleware for HTTP method POST
-					Use(controller.Middleware(http.MethodPost)).
-					// set final handler
-					Then(postPlural(controller)),
-			).Methods(http.MethodPost)
-			// add bulk POST request to OPTIONS list
-			allowedPlural.Add(http.MethodPost)
-
-			log.Printf("[POST] %s\n", path.Join(basePath...))
+	} else {
+		// inject dependencies to the controllers (wrapped by adapter if any)
+		if err = h.Apply(unwrap(resource)); err != nil {
+			h.logger.Error("dependency injection failed", "module", module, "controller", controllerPath, "error", err)
+			return &ControllerError{module, controllerPath, err}
 		}
-		// [POST] single
-		if controller, ok := resource.(SinglePoster); ok {
-			h.Router.Handle(
-				path.Join(append(basePath, "{pk}")...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), mw.BodyClose, GorillaParams).
-					// extract custom (user defined) middleware for HTTP method POST
-					Use(controller.Middleware(http.MethodPost)).
-					// set final handler
-					Then(postSingle(controller)),
-			).Methods(http.MethodPost)
-			// add single POST request to OPTIONS list
-			allowedSingle.Add(http.MethodPost)
-
-			log.Printf("[POST] %s\n", path.Join(append(basePath, "{pk}")...))
+		// init current controller first and if failed stop registration
+		if err = resource.Init(); err != nil {
+			h.logger.Error("controller init failed", "module", module, "controller", controllerPath, "error", err)
+			return &ControllerError{module, controllerPath, err}
 		}
-		// [PATCH] plural
-		if controller, ok := resource.(PluralPatcher); ok {
-			h.Router.Handle(
-				path.Join(basePath...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), mw.BodyClose, GorillaParams).
-					// extract custom (user defined) middleware for HTTP method PATCH
-					Use(controller.Middleware(http.MethodPatch)).
-					// set final handler
-					Then(patchPlural(controller)),
-			).Methods(http.MethodPatch)
-			// add bulk PATCH request to OPTIONS list
-			allowedPlural.Add(http.MethodPatch)
-
-			log.Printf("[PATCH] %s\n", path.Join(basePath...))
+		g.started = append(g.started, started{module, controllerPath, basePath, resource})
+	}
+	g.controllers[basePath] = resource
+	// controller may accept its own codecs and decoding settings
+	cfg = cfg.with(unwrap(resource))
+	singlePath := basePath
+	// primary keys of the parent controllers and own primary key(s)
+	singleKeys := append([]keyParam{}, parentKeys...)
+	ownKeys := []string{}
+	for _, key := range controllerKeys(unwrap(resource)) {
+		singlePath = path.Join(singlePath, "{"+key.Name+"}")
+		singleKeys = append(singleKeys, keyParam{key.Name, key})
+		ownKeys = append(ownKeys, key.Name)
+	}
+	// validate primary keys before calling the controller middleware/action
+	pluralValidator := validateKeys(parentKeys, nil)
+	singleValidator := validateKeys(singleKeys, ownKeys)
+	// route describes the controller action mounted by provided method and path
+	route := func(method, pattern, action string) Route {
+		return Route{Method: method, Path: pattern, Module: module, Controller: controllerPath, Action: action}
+	}
+	// list of available methods for current resource (required for OPTIONS request)
+	var allowedSingle = &Methods{}
+	var allowedPlural = &Methods{}
+	// methods of custom actions (added to OPTIONS list after CRUD methods)
+	var actionsSingle = &Methods{}
+	var actionsPlural = &Methods{}
+
+	// custom actions (should be mounted first, otherwise plural actions would
+	// be shadowed by single CRUD routes with the same HTTP method)
+	if controller, ok := unwrap(resource).(Actioner); ok {
+		actions := controller.Actions()
+		names := make([]string, 0, len(actions))
+		for name := range actions {
+			names = append(names, name)
 		}
-		// [PATCH] single
-		if controller, ok := resource.(SinglePatcher); ok {
-			h.Router.Handle(
-				path.Join(append(basePath, "{pk}")...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), mw.BodyClose, GorillaParams).
-					// extract custom (user defined) middleware for HTTP method PATCH
-					Use(controller.Middleware(http.MethodPatch)).
-					// set final handler
-					Then(patchSingle(controller)),
-			).Methods(http.MethodPatch)
-			// add single PATCH request to OPTIONS list
-			allowedSingle.Add(http.MethodPatch)
-
-			log.Printf("[PATCH] %s\n", path.Join(append(basePath, "{pk}")...))
+		sort.Strings(names)
+
+		for _, name := range names {
+			action := actions[name]
+			method := action.Method
+			if method == "" {
+				method = http.MethodPost
+			}
+			if action.Single != nil {
+				actionPath := path.Join(singlePath, name)
+				h.mount(g, route(method, actionPath, name), resource, cfg, singleValidator, singleAction(action.Single))
+				h.mount(g, route(http.MethodOptions, actionPath, "Options"), resource, cfg, nil, options(&Methods{method}))
+				actionsSingle.Add(method)
+			}
+			if action.Plural != nil {
+				actionPath := path.Join(basePath, name)
+				h.mount(g, route(method, actionPath, name), resource, cfg, pluralValidator, pluralAction(action.Plural))
+				h.mount(g, route(http.MethodOptions, actionPath, "Options"), resource, cfg, nil, options(&Methods{method}))
+				actionsPlural.Add(method)
+			}
 		}
-		// [PUT] plural
-		if controller, ok := resource.(PluralPutter); ok {
-			h.Router.Handle(
-				path.Join(basePath...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), mw.BodyClose, GorillaParams).
-					// extract custom (user defined) middleware for HTTP method PUT
-					Use(controller.Middleware(http.MethodPut)).
-					// set final handler
-					Then(putPlural(controller)),
-			).Methods(http.MethodPut)
-			// add bulk PUT request to OPTIONS list
-			allowedPlural.Add(http.MethodPut)
-
-			log.Printf("[PUT] %s\n", path.Join(basePath...))
+	}
+	// [GET] plural (streaming)
+	if controller, ok := unwrap(resource).(StreamGetter); ok {
+		fallback, _ := resource.(PluralGetter)
+		if !implements(resource, "GetAll") {
+			fallback = nil
 		}
-		// [PUT] single
-		if controller, ok := resource.(SinglePutter); ok {
-			h.Router.Handle(
-				path.Join(append(basePath, "{pk}")...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), mw.BodyClose, GorillaParams).
-					// extract custom (user defined) middleware for HTTP method PUT
-					Use(controller.Middleware(http.MethodPut)).
-					// set final handler
-					Then(putSingle(controller)),
-			).Methods(http.MethodPut)
-			// add single PUT request to OPTIONS list
-			allowedSingle.Add(http.MethodPut)
-
-			log.Printf("[PUT] %s\n", path.Join(append(basePath, "{pk}")...))
+		h.mount(g, route(http.MethodGet, basePath, "GetAll"), resource, cfg, pluralValidator, streamPlural(controller, fallback))
+		// add bulk GET request to OPTIONS list
+		allowedPlural.Add(http.MethodGet)
+	} else if controller, ok := resource.(PluralGetter); ok && implements(resource, "GetAll") {
+		// [GET] plural
+		h.mount(g, route(http.MethodGet, basePath, "GetAll"), resource, cfg, pluralValidator, getPlural(controller))
+		// add bulk GET request to OPTIONS list
+		allowedPlural.Add(http.MethodGet)
+	}
+	// [GET] single
+	if controller, ok := resource.(SingleGetter); ok && implements(resource, "Get") {
+		h.mount(g, route(http.MethodGet, singlePath, "Get"), resource, cfg, singleValidator, getSingle(controller))
+		// add single GET request to OPTIONS list
+		allowedSingle.Add(http.MethodGet)
+	}
+	// [POST] plural (streaming)
+	if controller, ok := unwrap(resource).(StreamPoster); ok {
+		var fallback http.HandlerFunc
+		if plural, ok := resource.(PluralPoster); ok && implements(resource, "PostAll") {
+			fallback = postPlural(plural)
 		}
-		// [DELETE] plural
-		if controller, ok := resource.(PluralDeleter); ok {
-			h.Router.Handle(
-				path.Join(basePath...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), mw.BodyClose, GorillaParams).
-					// extract custom (user defined) middleware for HTTP method DELETE
-					Use(controller.Middleware(http.MethodDelete)).
-					// set final handler
-					Then(deletePlural(controller)),
-			).Methods(http.MethodDelete)
-			// add bulk DELETE request to OPTIONS list
-			allowedPlural.Add(http.MethodDelete)
-
-			log.Printf("[DELETE] %s\n", path.Join(basePath...))
+		h.mount(g, route(http.MethodPost, basePath, "PostAll"), resource, cfg, pluralValidator, streamItems(func(r *http.Request, f func(interface{}) error) error {
+			return controller.PostItem(r.Context(), f)
+		}, fallback))
+		// add bulk POST request to OPTIONS list
+		allowedPlural.Add(http.MethodPost)
+	} else if controller, ok := resource.(PluralPoster); ok && implements(resource, "PostAll") {
+		// [POST] plural
+		h.mount(g, route(http.MethodPost, basePath, "PostAll"), resource, cfg, pluralValidator, postPlural(controller))
+		// add bulk POST request to OPTIONS list
+		allowedPlural.Add(http.MethodPost)
+	}
+	// [POST] single
+	if controller, ok := resource.(SinglePoster); ok && implements(resource, "Post") {
+		h.mount(g, route(http.MethodPost, singlePath, "Post"), resource, cfg, singleValidator, postSingle(controller))
+		// add single POST request to OPTIONS list
+		allowedSingle.Add(http.MethodPost)
+	}
+	// [PATCH] plural
+	if controller, ok := resource.(PluralPatcher); ok && implements(resource, "PatchAll") {
+		h.mount(g, route(http.MethodPatch, basePath, "PatchAll"), resource, cfg, pluralValidator, patchPlural(controller))
+		// add bulk PATCH request to OPTIONS list
+		allowedPlural.Add(http.MethodPatch)
+	}
+	// [PATCH] single
+	if controller, ok := resource.(SinglePatcher); ok && implements(resource, "Patch") {
+		h.mount(g, route(http.MethodPatch, singlePath, "Patch"), resource, cfg, singleValidator, patchSingle(controller))
+		// add single PATCH request to OPTIONS list
+		allowedSingle.Add(http.MethodPatch)
+	}
+	// [PUT] plural (streaming)
+	if controller, ok := unwrap(resource).(StreamPutter); ok {
+		var fallback http.HandlerFunc
+		if plural, ok := resource.(PluralPutter); ok && implements(resource, "PutAll") {
+			fallback = putPlural(plural)
 		}
-		// [DELETE] single
-		if controller, ok := resource.(SingleDeleter); ok {
-			h.Router.Handle(
-				path.Join(append(basePath, "{pk}")...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), mw.Codec(errFn, driver.Global()), mw.BodyClose, GorillaParams).
-					// extract custom (user defined) middleware for HTTP method DELETE
-					Use(controller.Middleware(http.MethodDelete)).
-					// set final handler
-					Then(deleteSingle(controller)),
-			).Methods(http.MethodDelete)
-			// add single DELETE request to OPTIONS list
-			allowedSingle.Add(http.MethodDelete)
-
-			log.Printf("[DELETE] %s\n", path.Join(append(basePath, "{pk}")...))
+		h.mount(g, route(http.MethodPut, basePath, "PutAll"), resource, cfg, pluralValidator, streamItems(func(r *http.Request, f func(interface{}) error) error {
+			return controller.PutItem(r.Context(), r.URL.Query(), f)
+		}, fallback))
+		// add bulk PUT request to OPTIONS list
+		allowedPlural.Add(http.MethodPut)
+	} else if controller, ok := resource.(PluralPutter); ok && implements(resource, "PutAll") {
+		// [PUT] plural
+		h.mount(g, route(http.MethodPut, basePath, "PutAll"), resource, cfg, pluralValidator, putPlural(controller))
+		// add bulk PUT request to OPTIONS list
+		allowedPlural.Add(http.MethodPut)
+	}
+	// [PUT] single
+	if controller, ok := resource.(SinglePutter); ok && implements(resource, "Put") {
+		h.mount(g, route(http.MethodPut, singlePath, "Put"), resource, cfg, singleValidator, putSingle(controller))
+		// add single PUT request to OPTIONS list
+		allowedSingle.Add(http.MethodPut)
+	}
+	// [DELETE] plural
+	if controller, ok := resource.(PluralDeleter); ok && implements(resource, "DeleteAll") {
+		h.mount(g, route(http.MethodDelete, basePath, "DeleteAll"), resource, cfg, pluralValidator, deletePlural(controller))
+		// add bulk DELETE request to OPTIONS list
+		allowedPlural.Add(http.MethodDelete)
+	}
+	// [DELETE] single
+	if controller, ok := resource.(SingleDeleter); ok && implements(resource, "Delete") {
+		h.mount(g, route(http.MethodDelete, singlePath, "Delete"), resource, cfg, singleValidator, deleteSingle(controller))
+		// add single DELETE request to OPTIONS list
+		allowedSingle.Add(http.MethodDelete)
+	}
+	// add custom action methods to OPTIONS lists
+	for _, method := range *actionsPlural {
+		allowedPlural.Add(method)
+	}
+	for _, method := range *actionsSingle {
+		allowedSingle.Add(method)
+	}
+	// [OPTIONS] bulk
+	if !allowedPlural.Empty() {
+		h.mount(g, route(http.MethodOptions, basePath, "Options"), resource, cfg, nil, options(allowedPlural))
+	}
+	// [OPTIONS] single
+	if !allowedSingle.Empty() {
+		h.mount(g, route(http.MethodOptions, singlePath, "Options"), resource, cfg, nil, options(allowedSingle))
+	}
+	// nested controllers
+	if parent, ok := unwrap(resource).(Parent); ok {
+		// parent primary keys are prefixed with controller alias (to be unique)
+		childPrefix := basePath
+		childKeys := append([]keyParam{}, parentKeys...)
+		for _, key := range controllerKeys(unwrap(resource)) {
+			param := controllerPath + "." + key.Name
+			for _, curr := range parentKeys {
+				if curr.param == param {
+					return &ControllerError{module, controllerPath, fmt.Errorf("parent key %q already in use in %q", param, prefix)}
+				}
+			}
+			childPrefix = path.Join(childPrefix, "{"+param+"}")
+			childKeys = append(childKeys, keyParam{param, key})
 		}
-		// [OPTIONS] bulk
-		if !allowedPlural.Empty() {
-			h.Router.Handle(
-				path.Join(basePath...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), GorillaParams).
-					// extract custom (user defined) middleware for HTTP method OPTIONS
-					Use(resource.Middleware(http.MethodOptions)).
-					// set final handler
-					Then(options(allowedPlural)),
-			).Methods(http.MethodOptions)
-
-			log.Printf("[OPTIONS] %s\n", path.Join(basePath...))
+		children := parent.Children()
+		g.watch(children)
+		children.Controllers(func(childPath string, child Controller) bool {
+			g.fail(h.useController(g, childPrefix, cfg, childKeys, childPath, child))
+			return true
+		})
+	}
+	return err
+}
+
+// mount adds the final handler for provided HTTP method and path to the group applying
+// default middleware and custom (user defined) controller middleware.
+func (h *handler) mount(g *group, route Route, resource Controller, cfg routeConfig, keys mw.Middleware, final http.Handler) {
+	// apply default middleware
+	chain := append(append([]mw.Middleware{}, h.stack...), h.middleware...)
+	switch route.Method {
+	case http.MethodOptions:
+		// OPTIONS request does not need any codecs
+		chain = append(chain, GorillaParams)
+	case http.MethodGet:
+		// no need to close the body with mw.BodyClose
+		chain = append(chain, codecsMiddleware(cfg.codecs), GorillaParams)
+	default:
+		chain = append(chain, codecsMiddleware(cfg.codecs), mw.BodyClose, GorillaParams)
+	}
+	// validate primary keys (if required)
+	if keys != nil {
+		chain = append(chain, keys)
+	}
+	// extract custom (user defined) middleware for HTTP method
+	chain = append(chain, resource.Middleware(route.Method))
+
+	route.Type = fmt.Sprintf("%T", unwrap(resource))
+	route.resource = resource
+	route.Middleware = len(chain)
+	g.add(route, h.accessLog(route, mw.New(chain...).Then(limitBody(cfg.decoding, final))))
+	h.logRoute(route)
+}
+
+// Handle registers custom handler for the given path applying default middleware.
+func (h *handler) Handle(pattern string, handler http.Handler) {
+	chain := h.defaultMiddleware()
+	pattern = h.prefixed(pattern)
+	h.addRoute(Route{Method: "*", Path: pattern, Middleware: len(chain)}, handler)
+}
+
+// HandleFunc registers custom handler func for the given path applying default
+// middleware.
+func (h *handler) HandleFunc(pattern string, f func(http.ResponseWriter, *http.Request)) {
+	h.Handle(pattern, http.HandlerFunc(f))
+}
+
+// HandleMethod registers custom handler for the given HTTP method and path
+// applying default middleware.
+func (h *handler) HandleMethod(method, pattern string, handler http.Handler) {
+	chain := h.defaultMiddleware()
+	pattern = h.prefixed(pattern)
+	h.addRoute(Route{Method: method, Path: pattern, Middleware: len(chain)}, handler)
+}
+
+// Routes returns the list of registered routes (in registration order).
+func (h *handler) Routes() []Route {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var routes []Route
+	for _, g := range h.groups {
+		for _, e := range g.entries {
+			routes = append(routes, e.route)
 		}
-		// [OPTIONS] single
-		if !allowedSingle.Empty() {
-			h.Router.Handle(
-				path.Join(append(basePath, "{pk}")...),
-				// apply default middleware
-				mw.New(mw.PanicRecover(errors.Send), GorillaParams).
-					// extract custom (user defined) middleware for HTTP method OPTIONS
-					Use(resource.Middleware(http.MethodOptions)).
-					// set final handler
-					Then(options(allowedSingle)),
-			).Methods(http.MethodOptions)
-
-			log.Printf("[OPTIONS] %s\n", path.Join(append(basePath, "{pk}")...))
+	}
+	return routes
+}
+
+// addRoute adds custom route to the route table applying default middleware.
+func (h *handler) addRoute(route Route, handler http.Handler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// subsequent custom routes share the same group
+	var g *group
+	if last := len(h.groups) - 1; last >= 0 && h.groups[last].module == nil {
+		g = h.groups[last]
+	} else {
+		g = &group{}
+		h.groups = append(h.groups, g)
+	}
+	g.add(route, h.accessLog(route, mw.New(h.defaultMiddleware()...).Then(handler)))
+	h.build()
+	h.logRoute(route)
+}
+
+// logRoute logs registered route.
+func (h *handler) logRoute(route Route) {
+	h.logger.Info("route registered",
+		"method", route.Method,
+		"path", route.Path,
+		"module", route.Module,
+		"controller", route.Controller,
+		"action", route.Action,
+	)
+}
+
+// SetErrorRenderer replaces the renderer used to send errors to the client (it
+// should be called before serving requests).
+func (h *handler) SetErrorRenderer(renderer ErrorRenderer) {
+	h.renderer = renderer
+}
+
+// SetLogger replaces the logger used for route registration, controller init and
+// dependency injection failures and access logs (DiscardLogger silences it). It
+// should be called before the modules are used.
+func (h *handler) SetLogger(logger Logger) {
+	if logger == nil {
+		logger = DiscardLogger
+	}
+	h.logger = logger
+}
+
+// defaultMiddleware returns the middleware chain applied to custom routes (the
+// same one that is used by controller actions).
+func (h *handler) defaultMiddleware() []mw.Middleware {
+	chain := append(append([]mw.Middleware{}, h.stack...), h.middleware...)
+	return append(chain, codecsMiddleware(h.codecs), GorillaParams)
+}
+
+// prefixed adds base path of the handler to the custom route pattern (keeping
+// trailing slash).
+func (h *handler) prefixed(pattern string) string {
+	if h.basePath == "" {
+		return pattern
+	}
+	return strings.TrimSuffix(path.Join("/", h.basePath), "/") + pattern
+}
+
+// ServeHTTP puts error renderer and logger of the handler into the request context and
+// dispatches the request to the matching route. If trailing slash is not significant
+// and no route matches the path, it is served by the route without trailing slash.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the request is copied, so the caller's request is never modified
+	ctx := context.WithValue(r.Context(), rendererKey{}, h.renderer)
+	r = r.WithContext(context.WithValue(ctx, loggerKey{}, h.logger))
+	if !h.drain.enter() {
+		h.unavailable(w, r)
+		return
+	}
+	defer h.drain.leave()
+
+	router := h.router.Load().(*mux.Router)
+	if !h.strictSlash && len(r.URL.Path) > 1 && strings.HasSuffix(r.URL.Path, "/") && !router.Match(r, &mux.RouteMatch{}) {
+		u := *r.URL
+		if u.Path, u.RawPath = strings.TrimRight(u.Path, "/"), ""; u.Path == "" {
+			u.Path = "/"
 		}
-		return true
-	})
-	// store alias and module to local registry in order to avoid duplicates
-	h.modules[alias] = module
+		r.URL = &u
+	}
+	router.ServeHTTP(w, r)
+}
 
-	return err
+// recoverer is a middleware that renders an error if the next handler panics.
+func (h *handler) recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				// let the server abort the response
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				RenderError(w, r, rec)
+			}
+		}()
+		// call the next handler
+		next.ServeHTTP(w, r)
+	})
 }
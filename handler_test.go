@@ -2,13 +2,20 @@ package lite
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	mw "github.com/tiny-go/middleware"
 )
 
 func Test_Handler(t *testing.T) {
 	t.Run("Given an HTTP handler", func(t *testing.T) {
-		handler := NewHandler()
+		handler := NewHandler(WithLogger(DiscardLogger))
 		t.Run("should register module with provided alias", func(t *testing.T) {
 			if handler.Use("one", NewBaseModule()) != nil {
 				t.Error("should not return an error")
@@ -21,3 +28,277 @@ func Test_Handler(t *testing.T) {
 		})
 	})
 }
+
+func Test_RemoveModule(t *testing.T) {
+	t.Run("Given an HTTP handler with a module", func(t *testing.T) {
+		driver.Default("application/json")
+		handler := NewHandler(WithLogger(DiscardLogger))
+		module := NewBaseModule()
+		module.Register("users", newPassController())
+		handler.Use("one", module)
+		get := func(target string) int {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			return w.Code
+		}
+		t.Run("should serve the routes of the module", func(t *testing.T) {
+			if code := get("/one/users/1"); code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", code, http.StatusOK)
+			}
+		})
+		t.Run("should drop the routes of removed module", func(t *testing.T) {
+			if err := handler.Remove("one"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if code := get("/one/users/1"); code != http.StatusNotFound {
+				t.Errorf("status code %d was expected to be %d", code, http.StatusNotFound)
+			}
+			if len(handler.Routes()) != 0 {
+				t.Errorf("routes %v were expected to be removed", handler.Routes())
+			}
+		})
+		t.Run("should return an error if module is not found", func(t *testing.T) {
+			if !reflect.DeepEqual(handler.Remove("one"), errors.New("module not found \"one\"")) {
+				t.Error("should return \"not found\" error")
+			}
+		})
+		t.Run("should not react on changes of removed module", func(t *testing.T) {
+			module.Register("posts", newPassController())
+			if code := get("/one/posts/1"); code != http.StatusNotFound {
+				t.Errorf("status code %d was expected to be %d", code, http.StatusNotFound)
+			}
+		})
+		t.Run("should allow to use the alias again", func(t *testing.T) {
+			again := NewBaseModule()
+			if err := handler.Use("one", again); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			again.Register("posts", newPassController())
+			if code := get("/one/posts/1"); code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", code, http.StatusOK)
+			}
+		})
+	})
+	t.Run("Given a request being served by the module", func(t *testing.T) {
+		handler := NewHandler(WithLogger(DiscardLogger))
+		controller := &mockBlockingController{mw.NewBaseController(), make(chan struct{}), make(chan struct{})}
+		module := NewBaseModule()
+		module.Register("slow", controller)
+		handler.Use("one", module)
+		t.Run("should finish the request after the module has been removed", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/one/slow/1", nil))
+			}()
+			<-controller.started
+			handler.Remove("one")
+			close(controller.release)
+			<-done
+			if w.Code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusOK)
+			}
+		})
+	})
+}
+
+func Test_ModuleChanges(t *testing.T) {
+	t.Run("Given an HTTP handler with a module", func(t *testing.T) {
+		driver.Default("application/json")
+		handler := NewHandler(WithLogger(DiscardLogger))
+		users := &mockCountingController{Controller: mw.NewBaseController()}
+		module := NewBaseModule()
+		module.Register("users", users)
+		handler.Use("one", module)
+		get := func(target string) int {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			return w.Code
+		}
+		t.Run("should mount the controller registered after the module has been used", func(t *testing.T) {
+			posts := &mockCountingController{Controller: mw.NewBaseController()}
+			module.Register("posts", posts)
+			if code := get("/one/posts/1"); code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", code, http.StatusOK)
+			}
+			if posts.Inits != 1 {
+				t.Errorf("new controller was initialized %d times", posts.Inits)
+			}
+		})
+		t.Run("should not initialize mounted controllers again", func(t *testing.T) {
+			if users.Inits != 1 {
+				t.Errorf("controller was initialized %d times", users.Inits)
+			}
+		})
+		t.Run("should drop the routes of unregistered controller", func(t *testing.T) {
+			module.Unregister("posts")
+			if code := get("/one/posts/1"); code != http.StatusNotFound {
+				t.Errorf("status code %d was expected to be %d", code, http.StatusNotFound)
+			}
+			if code := get("/one/users/1"); code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", code, http.StatusOK)
+			}
+		})
+		t.Run("should keep the routes if new controller cannot be initialized", func(t *testing.T) {
+			module.Register("broken", &mockInitController{mw.NewBaseController(), errors.New("init error")})
+			if code := get("/one/users/1"); code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", code, http.StatusOK)
+			}
+		})
+	})
+}
+
+func Test_InitHooks(t *testing.T) {
+	t.Run("Given a controller calling the handler in Init", func(t *testing.T) {
+		driver.Default("application/json")
+		handler := NewHandler(WithLogger(DiscardLogger))
+		var routes []Route
+		module := NewBaseModule()
+		module.Register("users", &mockHookController{mw.NewBaseController(), func() error {
+			handler.HandleFunc("/ready", func(http.ResponseWriter, *http.Request) {})
+			routes = handler.Routes()
+			return nil
+		}})
+		t.Run("should initialize the controller without deadlock", func(t *testing.T) {
+			if err := handler.Use("one", module); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(routes) != 1 || routes[0].Path != "/ready" {
+				t.Errorf("unexpected routes %v", routes)
+			}
+			for _, target := range []string{"/ready", "/one/users/1"} {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+				if w.Code != http.StatusOK {
+					t.Errorf("status code of %q %d was expected to be %d", target, w.Code, http.StatusOK)
+				}
+			}
+		})
+	})
+}
+
+func Test_RemoveDuringInit(t *testing.T) {
+	t.Run("Given a plugin calling the handler in Init while its module is removed", func(t *testing.T) {
+		driver.Default("application/json")
+		handler := NewHandler(WithLogger(DiscardLogger))
+		module := NewBaseModule()
+		module.Register("users", newPassController())
+		handler.Use("one", module)
+		started, release := make(chan struct{}), make(chan struct{})
+		plugin := &mockHookController{mw.NewBaseController(), func() error {
+			close(started)
+			<-release
+			handler.Routes()
+			return nil
+		}}
+		t.Run("should not deadlock", func(t *testing.T) {
+			registered, removed := make(chan struct{}), make(chan struct{})
+			go func() {
+				defer close(registered)
+				module.Register("plugin", plugin)
+			}()
+			<-started
+			go func() {
+				defer close(removed)
+				handler.Remove("one")
+			}()
+			// let Remove take the lock of the handler before Init calls it
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+			for _, done := range []chan struct{}{registered, removed} {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatal("handler and module are deadlocked")
+				}
+			}
+			if len(handler.Routes()) != 0 {
+				t.Errorf("routes %v were expected to be removed", handler.Routes())
+			}
+		})
+	})
+}
+
+func Test_HandleCustomRoutes(t *testing.T) {
+	t.Run("Given an HTTP handler with custom routes", func(t *testing.T) {
+		driver.Default("application/json")
+		handler := NewHandler(WithLogger(DiscardLogger))
+		handler.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", mw.ResponseCodecFromContext(r.Context()).MimeType())
+		})
+		handler.HandleMethod(http.MethodPost, "/hooks/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(errors.New(ParamsFromContext(r.Context())["id"]))
+		}))
+		t.Run("should serve custom route through default middleware", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusOK)
+			}
+			if w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+			}
+		})
+		t.Run("should provide URI params and recover from panic", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/hooks/abcd", nil))
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusInternalServerError)
+			}
+		})
+		t.Run("should not match custom route with different HTTP method", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hooks/abcd", nil))
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusMethodNotAllowed)
+			}
+		})
+	})
+}
+
+func Test_NestedControllers(t *testing.T) {
+	t.Run("Given an HTTP handler with nested controllers", func(t *testing.T) {
+		driver.Default("application/json")
+		notes := NewBaseModule()
+		notes.Register("notes", &mockParamsController{mw.NewBaseController()})
+		items := NewBaseModule()
+		items.Register("items", &mockParent{newPassController(), notes})
+		module := NewBaseModule()
+		module.Register("orders", &mockParent{newPassController(), items})
+		handler := NewHandler(WithLogger(DiscardLogger))
+		if err := handler.Use("shop", module); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		t.Run("should mount parent routes", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shop/orders/1", nil))
+			if w.Body.String() != "\"1\"\n" {
+				t.Errorf("unexpected response %q", w.Body.String())
+			}
+		})
+		t.Run("should mount child routes under the parent single path", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shop/orders/1/items/2", nil))
+			if w.Body.String() != "\"2\"\n" {
+				t.Errorf("unexpected response %q", w.Body.String())
+			}
+		})
+		t.Run("should provide all ancestor keys to the nested controller", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shop/orders/1/items/2/notes/3", nil))
+			if w.Body.String() != "{\"items.pk\":\"2\",\"orders.pk\":\"1\",\"pk\":\"3\"}\n" {
+				t.Errorf("unexpected response %q", w.Body.String())
+			}
+		})
+		t.Run("should return an error if parent key is not unique", func(t *testing.T) {
+			children := NewBaseModule()
+			module := NewBaseModule()
+			module.Register("orders", &mockParent{newPassController(), children})
+			children.Register("orders", &mockParent{newPassController(), NewBaseModule()})
+			if err := NewHandler(WithLogger(DiscardLogger)).Use("shop", module); err == nil {
+				t.Error("should return an error")
+			}
+		})
+	})
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/tiny-go/codec/driver"
 	_ "github.com/tiny-go/codec/driver/json"
+	_ "github.com/tiny-go/codec/driver/xml"
+	_ "github.com/tiny-go/lite/codec/cbor"
 )
 
 func Test_All(t *testing.T) {
@@ -18,7 +20,7 @@ func Test_All(t *testing.T) {
 		module := NewBaseModule()
 		module.Register("pass", newPassController())
 		module.Register("fail", newFailController())
-		handler := NewHandler()
+		handler := NewHandler(WithLogger(DiscardLogger))
 		handler.Use("test", module)
 		ts := httptest.NewServer(handler)
 		defer ts.Close()
@@ -91,7 +93,7 @@ func Test_All(t *testing.T) {
 					r, _ := http.NewRequest(http.MethodPost, ts.URL+"/test/pass", strings.NewReader("{\"foo\":\"bar\"}"))
 					return r
 				}(),
-				code: http.StatusOK,
+				code: http.StatusCreated,
 				body: "{\"foo\":\"bar\"}\n",
 			},
 			{
@@ -109,8 +111,9 @@ func Test_All(t *testing.T) {
 					r, _ := http.NewRequest(http.MethodPost, ts.URL+"/test/pass/abcd", strings.NewReader("{\"foo\":\"bar\",\"pk\":\"abcd\"}\n"))
 					return r
 				}(),
-				code: http.StatusOK,
-				body: "{\"foo\":\"bar\",\"pk\":\"abcd\"}\n",
+				header: http.Header{"Location": []string{"/test/pass/abcd"}},
+				code:   http.StatusCreated,
+				body:   "{\"foo\":\"bar\",\"pk\":\"abcd\"}\n",
 			},
 			{
 				title: "single POST with failure",
@@ -238,6 +241,128 @@ func Test_All(t *testing.T) {
 				code: http.StatusBadRequest,
 				body: "single DELETE error\n",
 			},
+			{
+				title: "single custom action OPTIONS request",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodOptions, ts.URL+"/test/pass/abcd/reset", nil)
+					return r
+				}(),
+				header: http.Header{"Access-Control-Allow-Methods": []string{"POST"}},
+				code:   http.StatusOK,
+			},
+			{
+				title: "single custom action with success",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodPost, ts.URL+"/test/pass/abcd/reset", strings.NewReader("{\"foo\":\"bar\"}"))
+					return r
+				}(),
+				code: http.StatusOK,
+				body: "{\"foo\":\"bar\",\"pk\":\"abcd\"}\n",
+			},
+			{
+				title: "single custom action with failure",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodPost, ts.URL+"/test/fail/abcd/reset", strings.NewReader("{\"foo\":\"bar\"}"))
+					return r
+				}(),
+				code: http.StatusBadRequest,
+				body: "single reset error\n",
+			},
+			{
+				title: "plural custom action with success",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodPost, ts.URL+"/test/pass/reset?foo=bar", nil)
+					return r
+				}(),
+				code: http.StatusOK,
+				body: "{\"foo\":[\"bar\"]}\n",
+			},
+			{
+				title: "plural custom action with failure",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodPost, ts.URL+"/test/fail/reset", nil)
+					return r
+				}(),
+				code: http.StatusBadRequest,
+				body: "plural reset error\n",
+			},
+			{
+				title: "negotiated GET with quality values",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodGet, ts.URL+"/test/pass/abcd", nil)
+					r.Header.Set("Accept", "text/html, application/json;q=0.9, application/xml;q=0.8, text/plain;q=0")
+					return r
+				}(),
+				header: http.Header{"Content-Type": []string{"application/json"}, "Vary": []string{"Accept"}},
+				code:   http.StatusOK,
+				body:   "\"abcd\"\n",
+			},
+			{
+				title: "negotiated GET with wildcard",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodGet, ts.URL+"/test/pass/abcd", nil)
+					r.Header.Set("Accept", "application/*")
+					return r
+				}(),
+				header: http.Header{"Content-Type": []string{"application/json"}, "Vary": []string{"Accept"}},
+				code:   http.StatusOK,
+				body:   "\"abcd\"\n",
+			},
+			{
+				title: "negotiated GET with structured syntax suffix",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodGet, ts.URL+"/test/pass/abcd", nil)
+					r.Header.Set("Accept", "application/vnd.company.v2+json")
+					return r
+				}(),
+				header: http.Header{"Content-Type": []string{"application/vnd.company.v2+json"}, "Vary": []string{"Accept"}},
+				code:   http.StatusOK,
+				body:   "\"abcd\"\n",
+			},
+			{
+				title: "negotiated POST with excluded media type",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodPost, ts.URL+"/test/pass/abcd", strings.NewReader("{\"foo\":\"bar\"}"))
+					r.Header.Set("Accept", "application/json;q=0, image/*")
+					return r
+				}(),
+				header: http.Header{"Vary": []string{"Accept"}},
+				code:   http.StatusNotAcceptable,
+				body:   "not acceptable: \"application/json;q=0, image/*\"\n",
+			},
+			{
+				title: "negotiated GET with wildcard excluding default media type",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodGet, ts.URL+"/test/pass/abcd", nil)
+					r.Header.Set("Accept", "*/*;q=0.5, application/json;q=0")
+					return r
+				}(),
+				header: http.Header{"Content-Type": []string{"application/cbor"}, "Vary": []string{"Accept"}},
+				code:   http.StatusOK,
+				body:   "\x64abcd",
+			},
+			{
+				title: "negotiated GET with type wildcard excluding default media type",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodGet, ts.URL+"/test/pass/abcd", nil)
+					r.Header.Set("Accept", "application/*;q=0.5, application/json;q=0")
+					return r
+				}(),
+				header: http.Header{"Content-Type": []string{"application/cbor"}, "Vary": []string{"Accept"}},
+				code:   http.StatusOK,
+				body:   "\x64abcd",
+			},
+			{
+				title: "negotiated GET with unsupported media type",
+				request: func() *http.Request {
+					r, _ := http.NewRequest(http.MethodGet, ts.URL+"/test/fail/abcd", nil)
+					r.Header.Set("Accept", "text/html")
+					return r
+				}(),
+				header: http.Header{"Vary": []string{"Accept"}},
+				code:   http.StatusNotAcceptable,
+				body:   "not acceptable: \"text/html\"\n",
+			},
 		}
 
 		for _, tc := range testCases {
@@ -17,6 +17,13 @@ type PluralGetter interface {
 	GetAll(ctx context.Context, params url.Values) (interface{}, error)
 }
 
+// StreamGetter should be able to provide available models one by one calling the
+// emitter for each of them (it should stop if emitter returns an error).
+type StreamGetter interface {
+	Controller
+	StreamAll(ctx context.Context, params url.Values, emit func(item interface{}) error) error
+}
+
 // SinglePoster should be able to store a single model to the storage.
 type SinglePoster interface {
 	Controller
@@ -29,6 +36,15 @@ type PluralPoster interface {
 	PostAll(ctx context.Context, f func(v interface{}) error) (interface{}, error)
 }
 
+// StreamPoster should be able to store the models of bulk request one by one, it
+// is called for each item of the request body (and should decode it with provided
+// func, which returns io.EOF at the end of the stream). Returned error rejects the
+// item without stopping the request.
+type StreamPoster interface {
+	Controller
+	PostItem(ctx context.Context, f func(v interface{}) error) error
+}
+
 // SinglePatcher should be able to patch a single model by primary key(s).
 type SinglePatcher interface {
 	Controller
@@ -53,6 +69,15 @@ type PluralPutter interface {
 	PutAll(ctx context.Context, params url.Values, f func(v interface{}) error) (interface{}, error)
 }
 
+// StreamPutter should be able to update the models of bulk request one by one, it
+// is called for each item of the request body (and should decode it with provided
+// func, which returns io.EOF at the end of the stream). Returned error rejects the
+// item without stopping the request.
+type StreamPutter interface {
+	Controller
+	PutItem(ctx context.Context, params url.Values, f func(v interface{}) error) error
+}
+
 // SingleDeleter should be able to delete a single model by primary key(s).
 type SingleDeleter interface {
 	Controller
@@ -64,3 +89,39 @@ type PluralDeleter interface {
 	Controller
 	DeleteAll(ctx context.Context, params url.Values) (interface{}, error)
 }
+
+// Keyer should be able to provide primary key schema, for composite keys routes
+// like "/{alias}/{controller}/{tenant}/{id}" are generated and the values are
+// joined with "/" (e.g. "tenant/id") before passing to the single actions.
+type Keyer interface {
+	Controller
+	Keys() []Key
+}
+
+// Action describes custom (non-CRUD) controller action, at least one of the
+// handler funcs should be provided.
+type Action struct {
+	// Method is an HTTP method of the action (POST is used if empty).
+	Method string
+	// Single should handle the action on a single model by primary key(s), it is
+	// mounted as "/{alias}/{controller}/{pk}/{action}".
+	Single func(ctx context.Context, pk string, f func(v interface{}) error) (interface{}, error)
+	// Plural should handle the action on a list of models, it is mounted as
+	// "/{alias}/{controller}/{action}".
+	Plural func(ctx context.Context, params url.Values, f func(v interface{}) error) (interface{}, error)
+}
+
+// Actioner should be able to provide a list of custom actions by their names.
+type Actioner interface {
+	Controller
+	Actions() map[string]Action
+}
+
+// Parent should be able to provide nested (child) controllers, they are mounted
+// under the single resource path of the parent controller, for instance
+// "/{alias}/{parent}/{parent.pk}/{child}/{pk}". Primary keys of the ancestors are
+// available with ParamsFromContext by ParentKey (e.g. "parent.pk").
+type Parent interface {
+	Controller
+	Children() Module
+}
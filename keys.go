@@ -0,0 +1,128 @@
+package lite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tiny-go/errors"
+	mw "github.com/tiny-go/middleware"
+)
+
+type keysKey struct{}
+
+// uuidRegexp matches canonical textual representation of UUID.
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Key describes a single part of (composite) primary key.
+type Key struct {
+	// Name is the name of URI parameter (should be unique within the controller).
+	Name string
+	// Parse should validate and convert the raw value (raw string is used if nil).
+	Parse func(string) (interface{}, error)
+}
+
+// StringKey creates primary key (part) that accepts any value except blank ones
+// (for instance "%20").
+func StringKey(name string) Key {
+	return Key{Name: name, Parse: func(v string) (interface{}, error) {
+		if strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("%q is blank", v)
+		}
+		return v, nil
+	}}
+}
+
+// IntKey creates primary key (part) that accepts only integers (parsed as int64).
+func IntKey(name string) Key {
+	return Key{Name: name, Parse: func(v string) (interface{}, error) {
+		return strconv.ParseInt(v, 10, 64)
+	}}
+}
+
+// UUIDKey creates primary key (part) that accepts only UUIDs (in canonical form).
+func UUIDKey(name string) Key {
+	return Key{Name: name, Parse: func(v string) (interface{}, error) {
+		if !uuidRegexp.MatchString(v) {
+			return nil, fmt.Errorf("%q is not a valid UUID", v)
+		}
+		return strings.ToLower(v), nil
+	}}
+}
+
+// RegexpKey creates primary key (part) that accepts only values matching provided
+// regular expression (panics if expression cannot be compiled).
+func RegexpKey(name, expr string) Key {
+	re := regexp.MustCompile(expr)
+	return Key{Name: name, Parse: func(v string) (interface{}, error) {
+		if !re.MatchString(v) {
+			return nil, fmt.Errorf("%q does not match %q", v, expr)
+		}
+		return v, nil
+	}}
+}
+
+// PrimaryKeys is a key/value map containing parsed primary keys (including keys
+// of the parent controllers) by URI parameter names.
+type PrimaryKeys map[string]interface{}
+
+// PrimaryKeysFromContext pulls parsed primary keys from a request context, or
+// returns nil if none are present.
+func PrimaryKeysFromContext(ctx context.Context) PrimaryKeys {
+	ks, _ := ctx.Value(keysKey{}).(PrimaryKeys)
+	return ks
+}
+
+// keyParam binds primary key (part) to the URI parameter.
+type keyParam struct {
+	param string
+	key   Key
+}
+
+// controllerKeys returns primary key schema of the controller ("pk" by default).
+func controllerKeys(resource Controller) []Key {
+	if keyer, ok := resource.(Keyer); ok {
+		if keys := keyer.Keys(); len(keys) > 0 {
+			return keys
+		}
+	}
+	return []Key{StringKey("pk")}
+}
+
+// validateKeys returns a middleware that parses primary keys from URI params
+// (responding with 400 if any of them is malformed) and puts them into the context.
+// Own keys of the controller (if provided) are joined with "/" and replace "pk"
+// URI param in order to be passed to the controller action.
+func validateKeys(params []keyParam, own []string) mw.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ps := ParamsFromContext(r.Context())
+			ks := make(PrimaryKeys, len(params))
+			for _, p := range params {
+				if p.key.Parse == nil {
+					ks[p.param] = ps[p.param]
+					continue
+				}
+				value, err := p.key.Parse(ps[p.param])
+				if err != nil {
+					RenderError(w, r, errors.BadRequestf("invalid primary key %q: %v", p.param, err))
+					return
+				}
+				ks[p.param] = value
+			}
+			if len(own) > 0 {
+				values := make([]string, len(own))
+				for i, param := range own {
+					values[i] = ps[param]
+				}
+				ps["pk"] = strings.Join(values, "/")
+			}
+			r = r.WithContext(context.WithValue(r.Context(), keysKey{}, ks))
+			// call the next handler
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package lite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	mw "github.com/tiny-go/middleware"
+)
+
+func Test_Keys(t *testing.T) {
+	t.Run("Given primary key constructors", func(t *testing.T) {
+		type testCase struct {
+			title string
+			key   Key
+			value string
+			valid bool
+			out   interface{}
+		}
+		testCases := []testCase{
+			{"string key should accept any value", StringKey("pk"), "abcd", true, "abcd"},
+			{"string key should keep spaces inside the value", StringKey("pk"), "a b", true, "a b"},
+			{"string key should reject empty values", StringKey("pk"), "", false, nil},
+			{"string key should reject blank values", StringKey("pk"), " \t", false, nil},
+			{"int key should parse integers", IntKey("pk"), "42", true, int64(42)},
+			{"int key should reject non-integers", IntKey("pk"), "abcd", false, nil},
+			{"UUID key should accept UUIDs", UUIDKey("pk"), "A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11", true, "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+			{"UUID key should reject malformed UUIDs", UUIDKey("pk"), "a0eebc99-9c0b", false, nil},
+			{"regexp key should accept matching values", RegexpKey("pk", "^[a-z]+$"), "abcd", true, "abcd"},
+			{"regexp key should reject values that do not match", RegexpKey("pk", "^[a-z]+$"), "ABCD", false, nil},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.title, func(t *testing.T) {
+				out, err := tc.key.Parse(tc.value)
+				if tc.valid && err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				if !tc.valid && err == nil {
+					t.Error("should return an error")
+				}
+				if tc.valid && out != tc.out {
+					t.Errorf("the output %v was expected to be %v", out, tc.out)
+				}
+			})
+		}
+	})
+}
+
+func Test_StringKey(t *testing.T) {
+	t.Run("Given an HTTP handler with default primary key", func(t *testing.T) {
+		driver.Default("application/json")
+		module := NewBaseModule()
+		module.Register("users", newPassController())
+		handler := NewHandler(WithLogger(DiscardLogger))
+		if err := handler.Use("test", module); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		t.Run("should pass valid key to the controller", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/users/john", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusOK)
+			}
+		})
+		t.Run("should respond with 400 if key is blank", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/users/%20", nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusBadRequest)
+			}
+			if expected := "invalid primary key \"pk\": \" \" is blank\n"; w.Body.String() != expected {
+				t.Errorf("response %q was expected to be %q", w.Body.String(), expected)
+			}
+		})
+	})
+}
+
+func Test_CompositeKeys(t *testing.T) {
+	t.Run("Given an HTTP handler with composite key controller", func(t *testing.T) {
+		driver.Default("application/json")
+		module := NewBaseModule()
+		module.Register("accounts", &mockKeyController{mw.NewBaseController()})
+		handler := NewHandler(WithLogger(DiscardLogger))
+		if err := handler.Use("test", module); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		t.Run("should pass joined and parsed keys to the controller", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/accounts/acme/42", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusOK)
+			}
+			if w.Body.String() != "{\"keys\":{\"id\":42,\"tenant\":\"acme\"},\"pk\":\"acme/42\"}\n" {
+				t.Errorf("unexpected response %q", w.Body.String())
+			}
+		})
+		t.Run("should respond with 400 if key is malformed", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/accounts/acme/abcd", nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	})
+}
@@ -0,0 +1,226 @@
+package lite
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/tiny-go/errors"
+)
+
+// Closer can be implemented by the controller in order to release resources
+// acquired in Init (it is called by Handler.Shutdown).
+type Closer interface {
+	Close() error
+}
+
+// Shutdowner can be implemented by the controller in order to release resources
+// acquired in Init within the deadline of Handler.Shutdown (it takes precedence
+// over Closer).
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// ControllerError is an error of the controller (its alias is used to report it).
+type ControllerError struct {
+	Module     string
+	Controller string
+	Err        error
+}
+
+// Error implements error interface.
+func (e *ControllerError) Error() string {
+	return fmt.Sprintf("module %q controller %q: %v", e.Module, e.Controller, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *ControllerError) Unwrap() error { return e.Err }
+
+// Errors is a list of errors returned if several operations have failed.
+type Errors []error
+
+// Error implements error interface.
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the list.
+func (e Errors) Unwrap() []error { return e }
+
+// Is reports whether any error of the list matches the target (errors.Is does not
+// unwrap lists of errors before Go 1.20).
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if stderrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error of the list that matches the target (errors.As does
+// not unwrap lists of errors before Go 1.20).
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if stderrors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// started is an initialized controller (that should be shut down).
+type started struct {
+	module     string
+	controller string
+	// path is the base path of the controller (unique within the module)
+	path     string
+	resource Controller
+}
+
+// shutdown calls shutdown hook of the controller (if any).
+func (s started) shutdown(ctx context.Context) error {
+	var err error
+	switch resource := unwrap(s.resource).(type) {
+	case Shutdowner:
+		err = resource.Shutdown(ctx)
+	case Closer:
+		err = resource.Close()
+	}
+	if err != nil {
+		return &ControllerError{s.module, s.controller, err}
+	}
+	return nil
+}
+
+// contains reports whether the controller is in the list (by module and path).
+func contains(list []started, s started) bool {
+	for _, curr := range list {
+		if curr.module == s.module && curr.path == s.path {
+			return true
+		}
+	}
+	return false
+}
+
+// without returns the list without provided controllers.
+func without(list, drop []started) []started {
+	rest := make([]started, 0, len(list))
+	for _, curr := range list {
+		if !contains(drop, curr) {
+			rest = append(rest, curr)
+		}
+	}
+	return rest
+}
+
+// running returns the controllers of the list that have not been shut down yet
+// (they are still in the active list).
+func running(list, active []started) []started {
+	var rest []started
+	for _, curr := range list {
+		if contains(active, curr) {
+			rest = append(rest, curr)
+		}
+	}
+	return rest
+}
+
+// shutdownAll calls shutdown hooks of the controllers in reverse order returning
+// the errors of all the failed ones.
+func shutdownAll(ctx context.Context, list []started) error {
+	var errs Errors
+	for i := len(list) - 1; i >= 0; i-- {
+		if err := list[i].shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Shutdown stops accepting new requests (they are rejected with 503), waits for
+// in-flight requests to finish and calls shutdown hooks of the controllers in
+// reverse order of initialization. If the context expires before all requests are
+// finished its error is returned and the hooks are not called (so Shutdown may
+// be called again).
+func (h *handler) Shutdown(ctx context.Context) error {
+	select {
+	case <-h.drain.close():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	h.mu.Lock()
+	// the controllers are not running anymore, so Remove and rebuild of the
+	// modules do not shut them down again
+	list := h.started
+	h.started = nil
+	h.mu.Unlock()
+
+	h.logger.Info("shutting down", "controllers", len(list))
+	return shutdownAll(ctx, list)
+}
+
+// drain counts the requests being served and rejects new ones once it is closed.
+type drain struct {
+	mu      sync.Mutex
+	closing bool
+	active  int
+	idle    chan struct{}
+}
+
+// enter registers new request (returns false if drain is closed).
+func (d *drain) enter() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closing {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// leave marks the request as finished.
+func (d *drain) leave() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// no requests are accepted after drain has been closed, so it becomes idle
+	// only once
+	if d.active--; d.active == 0 && d.idle != nil {
+		close(d.idle)
+	}
+}
+
+// close rejects new requests and returns the channel closed once all the active
+// requests are finished.
+func (d *drain) close() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.closing {
+		d.closing, d.idle = true, make(chan struct{})
+		if d.active == 0 {
+			close(d.idle)
+		}
+	}
+	return d.idle
+}
+
+// unavailable renders 503 error (the handler is shutting down).
+func (h *handler) unavailable(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Connection", "close")
+	RenderError(w, r, errors.NewStatusError(http.StatusServiceUnavailable,
+		fmt.Errorf("server is shutting down")))
+}
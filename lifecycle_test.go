@@ -0,0 +1,229 @@
+package lite
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	mw "github.com/tiny-go/middleware"
+)
+
+func Test_Shutdown(t *testing.T) {
+	t.Run("Given an HTTP handler with controllers implementing shutdown hooks", func(t *testing.T) {
+		driver.Default("application/json")
+		var closed []string
+		closeErr := errors.New("close error")
+		handler := NewHandler(WithLogger(DiscardLogger))
+		first := NewBaseModule()
+		first.Register("db", &mockClosingController{mw.NewBaseController(), "db", &closed, closeErr})
+		handler.Use("one", first)
+		second := NewBaseModule()
+		second.Register("cache", &mockShutdownController{&mockClosingController{mw.NewBaseController(), "cache", &closed, nil}})
+		second.Register("plain", newPassController())
+		handler.Use("two", second)
+		t.Run("should call the hooks in reverse order and report the errors", func(t *testing.T) {
+			err := handler.Shutdown(context.Background())
+			if !reflect.DeepEqual(closed, []string{"cache:shutdown", "db"}) {
+				t.Errorf("controllers were closed in unexpected order %v", closed)
+			}
+			expected := Errors{&ControllerError{"one", "db", errors.New("close error")}}
+			if !reflect.DeepEqual(err, expected) {
+				t.Errorf("error %v was expected to be %v", err, expected)
+			}
+			if err.Error() != `module "one" controller "db": close error` {
+				t.Errorf("unexpected error message %q", err.Error())
+			}
+			var controllerErr *ControllerError
+			if !errors.As(err, &controllerErr) || controllerErr.Controller != "db" {
+				t.Errorf("controller error was expected to be found in %v", err)
+			}
+			if !errors.Is(err, closeErr) {
+				t.Errorf("close error was expected to be found in %v", err)
+			}
+		})
+		t.Run("should reject new requests", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/two/plain/1", nil))
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusServiceUnavailable)
+			}
+		})
+		t.Run("should not call the hooks again", func(t *testing.T) {
+			if err := handler.Shutdown(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if len(closed) != 2 {
+				t.Errorf("controllers were closed again %v", closed)
+			}
+		})
+	})
+	t.Run("Given a request being served", func(t *testing.T) {
+		var closed []string
+		handler := NewHandler(WithLogger(DiscardLogger))
+		controller := &mockBlockingController{mw.NewBaseController(), make(chan struct{}), make(chan struct{})}
+		module := NewBaseModule()
+		module.Register("slow", controller)
+		module.Register("db", &mockClosingController{mw.NewBaseController(), "db", &closed, nil})
+		handler.Use("one", module)
+
+		w := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/one/slow/1", nil))
+		}()
+		<-controller.started
+		t.Run("should return context error if the request is not finished in time", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if err := handler.Shutdown(ctx); err != context.Canceled {
+				t.Errorf("error %v was expected to be %v", err, context.Canceled)
+			}
+			if len(closed) != 0 {
+				t.Errorf("controllers should not be closed %v", closed)
+			}
+		})
+		t.Run("should wait for the request before calling the hooks", func(t *testing.T) {
+			result := make(chan error)
+			go func() { result <- handler.Shutdown(context.Background()) }()
+			close(controller.release)
+			<-done
+			if err := <-result; err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if w.Code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusOK)
+			}
+			if !reflect.DeepEqual(closed, []string{"db"}) {
+				t.Errorf("unexpected list of closed controllers %v", closed)
+			}
+		})
+	})
+}
+
+func Test_RemoveShutdown(t *testing.T) {
+	t.Run("Given an HTTP handler with a module of closing controllers", func(t *testing.T) {
+		driver.Default("application/json")
+		var closed []string
+		handler := NewHandler(WithLogger(DiscardLogger))
+		module := NewBaseModule()
+		module.Register("a", &mockClosingController{mw.NewBaseController(), "a", &closed, nil})
+		module.Register("b", &mockClosingController{mw.NewBaseController(), "b", &closed, nil})
+		module.Register("c", &mockClosingController{mw.NewBaseController(), "c", &closed, nil})
+		handler.Use("one", module)
+		t.Run("should close the controller dropped by the module", func(t *testing.T) {
+			module.Unregister("c")
+			if !reflect.DeepEqual(closed, []string{"c"}) {
+				t.Errorf("unexpected closed controllers %v", closed)
+			}
+		})
+		t.Run("should close the controllers of removed module once", func(t *testing.T) {
+			closed = nil
+			if err := handler.Remove("one"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(closed, []string{"b", "a"}) {
+				t.Errorf("unexpected closed controllers %v", closed)
+			}
+		})
+		t.Run("should close the controllers of the module used again once", func(t *testing.T) {
+			closed = nil
+			handler.Use("one", module)
+			if err := handler.Shutdown(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(closed, []string{"b", "a"}) {
+				t.Errorf("unexpected closed controllers %v", closed)
+			}
+		})
+	})
+}
+
+func Test_ShutdownBeforeRemove(t *testing.T) {
+	t.Run("Given an HTTP handler that has been shut down", func(t *testing.T) {
+		driver.Default("application/json")
+		var closed []string
+		handler := NewHandler(WithLogger(DiscardLogger))
+		module := NewBaseModule()
+		module.Register("db", &mockClosingController{mw.NewBaseController(), "db", &closed, nil})
+		module.Register("cache", &mockClosingController{mw.NewBaseController(), "cache", &closed, nil})
+		handler.Use("one", module)
+		handler.Shutdown(context.Background())
+		t.Run("should not close unregistered controller again", func(t *testing.T) {
+			module.Unregister("cache")
+			if !reflect.DeepEqual(closed, []string{"cache", "db"}) {
+				t.Errorf("unexpected closed controllers %v", closed)
+			}
+		})
+		t.Run("should not close the controllers of removed module again", func(t *testing.T) {
+			if err := handler.Remove("one"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(closed, []string{"cache", "db"}) {
+				t.Errorf("unexpected closed controllers %v", closed)
+			}
+		})
+	})
+}
+
+func Test_UseRollback(t *testing.T) {
+	t.Run("Given a module with controllers failing to initialize", func(t *testing.T) {
+		driver.Default("application/json")
+		var closed []string
+		handler := NewHandler(WithLogger(DiscardLogger))
+		module := NewBaseModule()
+		module.Register("db", &mockClosingController{mw.NewBaseController(), "db", &closed, errors.New("close error")})
+		module.Register("first", &mockInitController{mw.NewBaseController(), errors.New("first error")})
+		module.Register("second", &mockInitController{mw.NewBaseController(), errors.New("second error")})
+		err := handler.Use("one", module)
+		t.Run("should return the errors of all failed controllers", func(t *testing.T) {
+			errs, ok := err.(Errors)
+			if !ok || len(errs) != 3 {
+				t.Fatalf("unexpected error %v", err)
+			}
+			expected := map[string]bool{
+				`module "one" controller "first": first error`:   true,
+				`module "one" controller "second": second error`: true,
+				`module "one" controller "db": close error`:      true,
+			}
+			for _, err := range errs {
+				if _, ok := err.(*ControllerError); !ok || !expected[err.Error()] {
+					t.Errorf("unexpected error %v", err)
+				}
+			}
+		})
+		t.Run("should shut down initialized controllers", func(t *testing.T) {
+			if !reflect.DeepEqual(closed, []string{"db"}) {
+				t.Errorf("unexpected list of closed controllers %v", closed)
+			}
+		})
+		t.Run("should not mount any routes", func(t *testing.T) {
+			if len(handler.Routes()) != 0 {
+				t.Errorf("routes %v were not expected to be mounted", handler.Routes())
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/one/db/1", nil))
+			if w.Code != http.StatusNotFound {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusNotFound)
+			}
+		})
+		t.Run("should not register the alias", func(t *testing.T) {
+			if err := handler.Use("one", NewBaseModule()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+		t.Run("should not shut down rolled back controllers again", func(t *testing.T) {
+			if err := handler.Shutdown(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if len(closed) != 1 {
+				t.Errorf("controllers were closed again %v", closed)
+			}
+		})
+	})
+}
@@ -0,0 +1,149 @@
+package lite
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Logger is a structured logger used by the handler. Arguments are key/value pairs
+// (the interface is compatible with *slog.Logger from "log/slog").
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// DiscardLogger does not log anything (for instance in tests).
+var DiscardLogger Logger = discardLogger{}
+
+type discardLogger struct{}
+
+func (discardLogger) Debug(string, ...interface{}) {}
+func (discardLogger) Info(string, ...interface{})  {}
+func (discardLogger) Warn(string, ...interface{})  {}
+func (discardLogger) Error(string, ...interface{}) {}
+
+// StdLogger adapts standard logger (log.Default() if nil) to Logger interface,
+// key/value pairs are written as "key=value" after the message.
+func StdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return &stdLogger{l}
+}
+
+type stdLogger struct{ *log.Logger }
+
+func (l *stdLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args) }
+func (l *stdLogger) Info(msg string, args ...interface{})  { l.log("INFO", msg, args) }
+func (l *stdLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args) }
+func (l *stdLogger) Error(msg string, args ...interface{}) { l.log("ERROR", msg, args) }
+
+// log writes the message with level and key/value pairs.
+func (l *stdLogger) log(level, msg string, args []interface{}) {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fmt.Fprintf(&b, " !BADKEY=%v", args[i])
+			break
+		}
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	l.Output(3, b.String())
+}
+
+type loggerKey struct{}
+
+// loggerFromContext returns the logger of the handler serving the request.
+func loggerFromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return logger
+	}
+	return DiscardLogger
+}
+
+// accessLog wraps the route handler in order to log every request (after it has
+// been served) with the route details, status code, latency and response size
+// (if access log is enabled).
+func (h *handler) accessLog(route Route, next http.Handler) http.Handler {
+	if !h.logRequests {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		// log the request even if the handler panics
+		defer func() {
+			if sw.status == 0 {
+				sw.status = http.StatusOK
+			}
+			h.logger.Info("request",
+				"method", r.Method,
+				"path", route.Path,
+				"module", route.Module,
+				"controller", route.Controller,
+				"status", sw.status,
+				"latency", time.Since(start),
+				"bytes", sw.bytes,
+			)
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// statusWriter records the status code and the number of bytes written.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader records and writes the status code.
+func (sw *statusWriter) WriteHeader(code int) {
+	if sw.status == 0 {
+		sw.status = code
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the number of bytes written to the response.
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytes += n
+	return n, err
+}
+
+// Flush sends buffered data to the client (if supported by the writer).
+func (sw *statusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection (for instance for websockets).
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := sw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not support hijacking", sw.ResponseWriter)
+	}
+	if sw.status == 0 {
+		sw.status = http.StatusSwitchingProtocols
+	}
+	return hj.Hijack()
+}
+
+// Unwrap returns the original writer (used by http.ResponseController).
+func (sw *statusWriter) Unwrap() http.ResponseWriter { return sw.ResponseWriter }
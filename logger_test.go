@@ -0,0 +1,179 @@
+package lite
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	mw "github.com/tiny-go/middleware"
+)
+
+type mockEntry struct {
+	level, msg string
+	attrs      map[string]interface{}
+}
+
+type mockLogger struct {
+	sync.Mutex
+	entries []mockEntry
+}
+
+func (l *mockLogger) Debug(msg string, args ...interface{}) { l.add("DEBUG", msg, args) }
+func (l *mockLogger) Info(msg string, args ...interface{})  { l.add("INFO", msg, args) }
+func (l *mockLogger) Warn(msg string, args ...interface{})  { l.add("WARN", msg, args) }
+func (l *mockLogger) Error(msg string, args ...interface{}) { l.add("ERROR", msg, args) }
+
+func (l *mockLogger) add(level, msg string, args []interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	attrs := make(map[string]interface{})
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[fmt.Sprint(args[i])] = args[i+1]
+	}
+	l.entries = append(l.entries, mockEntry{level, msg, attrs})
+}
+
+func (l *mockLogger) find(msg string) []mockEntry {
+	l.Lock()
+	defer l.Unlock()
+	var found []mockEntry
+	for _, entry := range l.entries {
+		if entry.msg == msg {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+func Test_Logger(t *testing.T) {
+	t.Run("Given a handler with custom logger", func(t *testing.T) {
+		driver.Default("application/json")
+		logger := &mockLogger{}
+		handler := NewHandler(WithLogger(logger), WithAccessLog(true))
+		module := NewBaseModule()
+		module.Register("user", newPassController())
+		if err := handler.Use("test", module); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		t.Run("should log registered routes", func(t *testing.T) {
+			entries := logger.find("route registered")
+			if len(entries) != len(handler.Routes()) {
+				t.Fatalf("%d routes were expected to be logged but got %d", len(handler.Routes()), len(entries))
+			}
+			if entries[0].level != "INFO" || entries[0].attrs["module"] != "test" || entries[0].attrs["controller"] != "user" {
+				t.Errorf("unexpected entry %+v", entries[0])
+			}
+		})
+		t.Run("should log served requests", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/user/1", nil))
+			entries := logger.find("request")
+			if len(entries) != 1 {
+				t.Fatalf("one request was expected to be logged but got %d", len(entries))
+			}
+			attrs := entries[0].attrs
+			if attrs["method"] != http.MethodGet || attrs["path"] != "/test/user/{pk}" || attrs["status"] != http.StatusOK {
+				t.Errorf("unexpected entry %+v", entries[0])
+			}
+			if attrs["bytes"] != w.Body.Len() {
+				t.Errorf("%d bytes were expected to be logged but got %v", w.Body.Len(), attrs["bytes"])
+			}
+		})
+		t.Run("should log controller init failures", func(t *testing.T) {
+			module := NewBaseModule()
+			module.Register("broken", &mockInitController{mw.NewBaseController(), errors.New("init error")})
+			if err := handler.Use("broken", module); err == nil {
+				t.Fatal("an error was expected")
+			}
+			entries := logger.find("controller init failed")
+			if len(entries) != 1 || entries[0].level != "ERROR" || entries[0].attrs["module"] != "broken" {
+				t.Errorf("unexpected entries %+v", entries)
+			}
+		})
+		t.Run("should log dependency injection failures", func(t *testing.T) {
+			module := NewBaseModule()
+			module.Register("typed", &mockTypedController{Controller: mw.NewBaseController()})
+			if err := handler.Use("typed", module); err == nil {
+				t.Fatal("an error was expected")
+			}
+			if entries := logger.find("dependency injection failed"); len(entries) != 1 {
+				t.Errorf("unexpected entries %+v", entries)
+			}
+		})
+	})
+	t.Run("Given standard logger adapter", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := StdLogger(log.New(buf, "", 0))
+		t.Run("should write message with key/value pairs", func(t *testing.T) {
+			logger.Warn("message", "key", "value", "number", 1, "odd")
+			if expected := "WARN message key=value number=1 !BADKEY=odd\n"; buf.String() != expected {
+				t.Errorf("%q was expected to be %q", buf.String(), expected)
+			}
+		})
+	})
+	t.Run("Given status writer", func(t *testing.T) {
+		logger := &mockLogger{}
+		handler := NewHandler(WithLogger(logger), WithAccessLog(true))
+		handler.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) { panic("oops") })
+		t.Run("should log the status of recovered panic", func(t *testing.T) {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+			entries := logger.find("request")
+			if len(entries) != 1 || entries[0].attrs["status"] != http.StatusInternalServerError {
+				t.Errorf("unexpected entries %+v", entries)
+			}
+		})
+		t.Run("should expose the original writer", func(t *testing.T) {
+			var unwrapped http.ResponseWriter
+			handler.HandleFunc("/unwrap", func(w http.ResponseWriter, _ *http.Request) {
+				if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); ok {
+					unwrapped = u.Unwrap()
+				}
+			})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unwrap", nil))
+			if unwrapped != w {
+				t.Errorf("writer %v was expected to be unwrapped to the recorder", unwrapped)
+			}
+		})
+		t.Run("should allow to hijack the connection", func(t *testing.T) {
+			handler.HandleFunc("/hijack", func(w http.ResponseWriter, _ *http.Request) {
+				conn, buf, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				defer conn.Close()
+				buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+				buf.Flush()
+			})
+			server := httptest.NewServer(handler)
+			defer server.Close()
+			res, err := http.Get(server.URL + "/hijack")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusSwitchingProtocols {
+				t.Errorf("status code %d was expected to be %d", res.StatusCode, http.StatusSwitchingProtocols)
+			}
+		})
+	})
+	t.Run("Given a handler with default options", func(t *testing.T) {
+		logger := &mockLogger{}
+		handler := NewHandler(WithLogger(logger))
+		handler.HandleFunc("/healthz", func(http.ResponseWriter, *http.Request) {})
+		t.Run("should not log served requests", func(t *testing.T) {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if entries := logger.find("request"); len(entries) != 0 {
+				t.Errorf("unexpected entries %+v", entries)
+			}
+		})
+	})
+}
@@ -33,3 +33,8 @@ func ParamsFromContext(ctx context.Context) Params {
 	p, _ := ctx.Value(paramsKey{}).(Params)
 	return p
 }
+
+// ParentKey returns the name of URI parameter that contains primary key of the
+// parent controller with provided alias (for nested controllers). If parent has
+// a custom key schema its keys are available as "{alias}.{key name}".
+func ParentKey(alias string) string { return alias + ".pk" }
@@ -2,8 +2,13 @@ package lite
 
 import (
 	"context"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"strconv"
 
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/codec/driver"
 	"github.com/tiny-go/errors"
 	mw "github.com/tiny-go/middleware"
 )
@@ -19,6 +24,7 @@ var (
 	_ PluralPutter  = &mockController{}
 	_ SingleDeleter = &mockController{}
 	_ PluralDeleter = &mockController{}
+	_ Actioner      = &mockController{}
 )
 
 type mockController struct {
@@ -111,3 +117,317 @@ func (c *mockController) DeleteAll(_ context.Context, ps url.Values) (interface{
 	}
 	return ps, nil
 }
+
+func (c *mockController) Actions() map[string]Action {
+	return map[string]Action{
+		"reset": {
+			Single: func(_ context.Context, pk string, f func(v interface{}) error) (interface{}, error) {
+				if c.ShouldFail {
+					return nil, errors.BadRequest("single reset error")
+				}
+				data := map[string]interface{}{"pk": pk}
+				return data, f(&data)
+			},
+			Plural: func(_ context.Context, ps url.Values, f func(v interface{}) error) (interface{}, error) {
+				if c.ShouldFail {
+					return nil, errors.BadRequest("plural reset error")
+				}
+				return ps, nil
+			},
+		},
+	}
+}
+
+type mockParent struct {
+	*mockController
+	children Module
+}
+
+func (c *mockParent) Children() Module { return c.children }
+
+type mockParamsController struct {
+	mw.Controller
+}
+
+func (c *mockParamsController) Init() error { return nil }
+
+func (c *mockParamsController) Get(ctx context.Context, _ string) (interface{}, error) {
+	return ParamsFromContext(ctx), nil
+}
+
+type mockKeyController struct {
+	mw.Controller
+}
+
+func (c *mockKeyController) Init() error { return nil }
+
+func (c *mockKeyController) Keys() []Key {
+	return []Key{RegexpKey("tenant", "^[a-z]+$"), IntKey("id")}
+}
+
+func (c *mockKeyController) Get(ctx context.Context, pk string) (interface{}, error) {
+	return map[string]interface{}{"pk": pk, "keys": PrimaryKeysFromContext(ctx)}, nil
+}
+
+type mockTypedInput struct {
+	Foo string `json:"foo"`
+}
+
+type mockCamelModel struct {
+	FooBar string `json:"fooBar"`
+}
+
+type mockTypedController struct {
+	mw.Controller
+	Suffix string `inject:"t"`
+}
+
+func (c *mockTypedController) Init() error { return nil }
+
+func (c *mockTypedController) Get(_ context.Context, pk string) (string, error) {
+	return pk + c.Suffix, nil
+}
+
+func (c *mockTypedController) Post(_ context.Context, in *mockTypedInput) (*mockTypedInput, error) {
+	in.Foo += c.Suffix
+	return in, nil
+}
+
+type mockModel struct {
+	ID string `json:"id"`
+}
+
+func (m *mockModel) PrimaryKey() string { return m.ID }
+
+type mockDataController struct {
+	mw.Controller
+	Data interface{}
+}
+
+func (c *mockDataController) Init() error { return nil }
+
+func (c *mockDataController) PostAll(_ context.Context, _ func(v interface{}) error) (interface{}, error) {
+	return c.Data, nil
+}
+
+func (c *mockDataController) Get(_ context.Context, _ string) (interface{}, error) {
+	return c.Data, nil
+}
+
+func (c *mockDataController) Delete(_ context.Context, _ string) (interface{}, error) {
+	return c.Data, nil
+}
+
+func (c *mockTypedController) Describe(action string) *Description {
+	if action == "Get" {
+		return &Description{Summary: "Get typed model"}
+	}
+	return nil
+}
+
+type mockInitController struct {
+	mw.Controller
+	err error
+}
+
+func (c *mockInitController) Init() error { return c.err }
+
+type mockCodecsController struct {
+	*mockController
+	codecs *Codecs
+}
+
+func (c *mockCodecsController) Codecs() *Codecs { return c.codecs }
+
+type mockStreamController struct {
+	mw.Controller
+	Count int
+	Err   error
+}
+
+func (c *mockStreamController) Init() error { return nil }
+
+func (c *mockStreamController) StreamAll(_ context.Context, _ url.Values, emit func(interface{}) error) error {
+	for i := 0; i < c.Count; i++ {
+		if err := emit(&mockModel{ID: strconv.Itoa(i)}); err != nil {
+			return err
+		}
+	}
+	return c.Err
+}
+
+type mockBulkController struct {
+	mw.Controller
+	Stored []string
+	// Err is returned before the item is decoded
+	Err error
+}
+
+func (c *mockBulkController) Init() error { return nil }
+
+func (c *mockBulkController) PostItem(_ context.Context, f func(interface{}) error) error {
+	if c.Err != nil {
+		return c.Err
+	}
+	model := &mockModel{}
+	if err := f(model); err != nil {
+		return err
+	}
+	if model.ID == "" {
+		return errors.BadRequest("id is required")
+	}
+	c.Stored = append(c.Stored, model.ID)
+	return nil
+}
+
+func (c *mockBulkController) PutItem(ctx context.Context, _ url.Values, f func(interface{}) error) error {
+	return c.PostItem(ctx, f)
+}
+
+type mockUploadController struct {
+	mw.Controller
+	Files []*File
+}
+
+func (c *mockUploadController) Init() error { return nil }
+
+func (c *mockUploadController) Post(_ context.Context, f func(interface{}) error) (interface{}, error) {
+	upload := &Upload{MaxSize: 16}
+	if err := f(upload); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(upload.Body)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"type": upload.ContentType, "data": string(data)}, nil
+}
+
+func (c *mockUploadController) Put(_ context.Context, _ string, f func(interface{}) error) (interface{}, error) {
+	form := &Form{MaxSize: 1024, MaxMemory: 1}
+	if err := f(form); err != nil {
+		return nil, err
+	}
+	files := map[string]string{}
+	for field, list := range form.Files {
+		for _, file := range list {
+			c.Files = append(c.Files, file)
+			r, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			data, _ := ioutil.ReadAll(r)
+			r.Close()
+			files[field] = file.Filename + ":" + file.ContentType + ":" + string(data)
+		}
+	}
+	return map[string]interface{}{"values": form.Values, "files": files}, nil
+}
+
+type mockDecodingController struct {
+	mw.Controller
+	decoding *Decoding
+}
+
+func (c *mockDecodingController) Init() error { return nil }
+
+func (c *mockDecodingController) Decoding() *Decoding { return c.decoding }
+
+func (c *mockDecodingController) Post(_ context.Context, f func(interface{}) error) (interface{}, error) {
+	in := &mockTypedInput{}
+	return in, f(in)
+}
+
+type mockCountingController struct {
+	mw.Controller
+	Inits int
+}
+
+func (c *mockCountingController) Init() error {
+	c.Inits++
+	return nil
+}
+
+func (c *mockCountingController) Get(_ context.Context, pk string) (interface{}, error) {
+	return pk, nil
+}
+
+type mockBlockingController struct {
+	mw.Controller
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *mockBlockingController) Init() error { return nil }
+
+func (c *mockBlockingController) Get(_ context.Context, pk string) (interface{}, error) {
+	close(c.started)
+	<-c.release
+	return pk, nil
+}
+
+type mockTextCodec struct{}
+
+func (c *mockTextCodec) Encoder(w io.Writer) codec.Encoder { return nil }
+
+func (c *mockTextCodec) Decoder(r io.Reader) codec.Decoder { return nil }
+
+func (c *mockTextCodec) MimeType() string { return "text/x-lite-test" }
+
+// mockListingRegistry hides the list of codecs behind MediaTypeLister.
+type mockListingRegistry struct {
+	driver.DummyRegistry
+}
+
+func (r *mockListingRegistry) MediaTypes() []string {
+	var list []string
+	for _, c := range r.DummyRegistry {
+		list = append(list, c.MimeType())
+	}
+	return list
+}
+
+type mockHookController struct {
+	mw.Controller
+	init func() error
+}
+
+func (c *mockHookController) Init() error { return c.init() }
+
+func (c *mockHookController) Get(_ context.Context, pk string) (interface{}, error) {
+	return pk, nil
+}
+
+type mockClosingController struct {
+	mw.Controller
+	name   string
+	closed *[]string
+	err    error
+}
+
+func (c *mockClosingController) Init() error { return nil }
+
+func (c *mockClosingController) Get(_ context.Context, pk string) (interface{}, error) {
+	return pk, nil
+}
+
+func (c *mockClosingController) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return c.err
+}
+
+type mockShutdownController struct {
+	*mockClosingController
+}
+
+func (c *mockShutdownController) Shutdown(ctx context.Context) error {
+	*c.closed = append(*c.closed, c.name+":shutdown")
+	return ctx.Err()
+}
+
+type mockPriorityController struct {
+	*mockController
+	priority int
+}
+
+func (c *mockPriorityController) Priority() int { return c.priority }
@@ -5,8 +5,8 @@ import (
 	"sync"
 )
 
-// Module represents single module with lite API (it means that all its routes
-// can be generated only once at startup).
+// Module represents single module with lite API (its routes are generated when
+// the module is used by the handler and rebuilt only if it implements Notifier).
 type Module interface {
 	// Register should add Controller to module resources.
 	Register(alias string, controller Controller) error
@@ -17,11 +17,25 @@ type Module interface {
 	Controllers(func(alias string, controller Controller) bool)
 }
 
+// Notifier can be implemented by the module in order to notify the handler about
+// registered and unregistered controllers (routes of the module are rebuilt).
+type Notifier interface {
+	// OnChange adds the listener called after the list of controllers has been
+	// changed, returned func removes the listener.
+	OnChange(listener func()) (cancel func())
+}
+
 // BaseModule contains a basic set of logic and provides basic operations on
 // resources (like "Register", "Unregister" etc).
 type BaseModule struct {
 	sync.RWMutex
 	resources map[string]Controller
+	codecs    *Codecs
+	listeners map[int]func()
+	nextID    int
+	sequence  sequence
+	order     Order
+	info      ModuleInfo
 }
 
 // NewBaseModule is a constructor func for BaseModule.
@@ -32,36 +46,125 @@ func NewBaseModule() *BaseModule {
 // Register makes resource available by provided alias.
 func (m *BaseModule) Register(name string, resource Controller) error {
 	m.Lock()
-	defer m.Unlock()
 
 	if _, ok := m.resources[name]; ok {
+		m.Unlock()
 		return fmt.Errorf("already registered: %q", name)
 	}
 	m.resources[name] = resource
+	m.sequence.add(name)
+	m.Unlock()
+
+	m.notify()
 	return nil
 }
 
 // Unregister removes resource from the list by alias.
 func (m *BaseModule) Unregister(name string) error {
 	m.Lock()
-	defer m.Unlock()
 
 	if _, ok := m.resources[name]; !ok {
+		m.Unlock()
 		return fmt.Errorf("not registered: %q", name)
 	}
 	delete(m.resources, name)
+	m.sequence.remove(name)
+	m.Unlock()
+
+	m.notify()
 	return nil
 }
 
-// Controllers calls the provided func sequentially for each available resource.
-// If func returns false the "for" loop will be stopped.
+// Controllers calls the provided func sequentially for each available resource
+// (in order of registration unless other order is set). If func returns false the
+// "for" loop will be stopped.
 func (m *BaseModule) Controllers(f func(string, Controller) bool) {
 	m.Lock()
 	defer m.Unlock()
 
-	for alias, resource := range m.resources {
-		if !f(alias, resource) {
+	aliases := make([]string, 0, len(m.resources))
+	for alias := range m.resources {
+		aliases = append(aliases, alias)
+	}
+	for _, alias := range m.sequence.sort(aliases, m.order, func(alias string) interface{} { return m.resources[alias] }) {
+		if !f(alias, m.resources[alias]) {
 			break
 		}
 	}
 }
+
+// SetOrder sets the order of the controllers (it should be called before the
+// module is used by the handler).
+func (m *BaseModule) SetOrder(order Order) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.order = order
+}
+
+// SetCodecs sets request/response codecs accepted by the module controllers (it
+// should be called before the module is used by the handler).
+func (m *BaseModule) SetCodecs(codecs *Codecs) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.codecs = codecs
+}
+
+// Codecs returns own codecs of the module (nil if handler codecs are used).
+func (m *BaseModule) Codecs() *Codecs {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.codecs
+}
+
+// OnChange adds the listener called after the controller has been registered or
+// unregistered, returned func removes the listener.
+func (m *BaseModule) OnChange(listener func()) func() {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.listeners == nil {
+		m.listeners = make(map[int]func())
+	}
+	id := m.nextID
+	m.nextID++
+	m.listeners[id] = listener
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+
+		delete(m.listeners, id)
+	}
+}
+
+// notify calls the listeners (the module should not be locked).
+func (m *BaseModule) notify() {
+	m.RLock()
+	listeners := make([]func(), 0, len(m.listeners))
+	for _, listener := range m.listeners {
+		listeners = append(listeners, listener)
+	}
+	m.RUnlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// SetInfo sets metadata of the module (name, version and dependencies).
+func (m *BaseModule) SetInfo(info ModuleInfo) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.info = info
+}
+
+// Info returns metadata of the module.
+func (m *BaseModule) Info() ModuleInfo {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.info
+}
@@ -1,6 +1,9 @@
 package lite
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func Test_BaseModule(t *testing.T) {
 	t.Run("Given BaseModule", func(t *testing.T) {
@@ -59,3 +62,46 @@ func Test_BaseModule(t *testing.T) {
 		})
 	})
 }
+
+func Test_BaseModuleOrder(t *testing.T) {
+	t.Run("Given BaseModule with several controllers", func(t *testing.T) {
+		module := NewBaseModule()
+		module.Register("users", &mockPriorityController{newPassController(), 1})
+		module.Register("accounts", &mockPriorityController{newPassController(), 0})
+		module.Register("posts", &mockPriorityController{newPassController(), 2})
+		module.Register("comments", &mockPriorityController{newPassController(), 1})
+		list := func() (aliases []string) {
+			module.Controllers(func(alias string, _ Controller) bool {
+				aliases = append(aliases, alias)
+				return true
+			})
+			return aliases
+		}
+		cases := []struct {
+			title    string
+			order    Order
+			expected []string
+		}{
+			{"should iterate controllers in order of registration by default", RegistrationOrder, []string{"users", "accounts", "posts", "comments"}},
+			{"should iterate controllers in alphabetical order", AliasOrder, []string{"accounts", "comments", "posts", "users"}},
+			{"should iterate controllers by priority", PriorityOrder, []string{"posts", "users", "comments", "accounts"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.title, func(t *testing.T) {
+				module.SetOrder(tc.order)
+				if aliases := list(); !reflect.DeepEqual(aliases, tc.expected) {
+					t.Errorf("controllers %v were expected to be iterated as %v", aliases, tc.expected)
+				}
+			})
+		}
+		t.Run("should move re-registered controller to the end", func(t *testing.T) {
+			module.SetOrder(RegistrationOrder)
+			module.Unregister("users")
+			module.Register("users", newPassController())
+			expected := []string{"accounts", "posts", "comments", "users"}
+			if aliases := list(); !reflect.DeepEqual(aliases, expected) {
+				t.Errorf("controllers %v were expected to be iterated as %v", aliases, expected)
+			}
+		})
+	})
+}
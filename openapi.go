@@ -0,0 +1,335 @@
+package lite
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion is the version of OpenAPI specification generated by the handler.
+const OpenAPIVersion = "3.0.3"
+
+// pathParamRegexp matches URI params of the path template.
+var pathParamRegexp = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Description describes controller action for API specification.
+type Description struct {
+	// Summary is a short summary of the action.
+	Summary string
+	// Description is a verbose explanation of the action.
+	Description string
+	// Request is a sample of request model (only its type is used), nil if the
+	// action does not accept request body.
+	Request interface{}
+	// Response is a sample of response model (only its type is used).
+	Response interface{}
+	// Query contains query parameters of the action (for instance "GetAll").
+	Query []*Parameter
+}
+
+// Describer should be able to describe controller actions (by action name, for
+// instance "Get", "GetAll" or custom action name), nil means no description.
+type Describer interface {
+	Describe(action string) *Description
+}
+
+// Info provides metadata about the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Document is a root object of OpenAPI specification.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+// PathItem describes operations available on a single path (by lower case HTTP
+// method).
+type PathItem map[string]*Operation
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string                        `json:"operationId,omitempty"`
+	Tags        []string                      `json:"tags,omitempty"`
+	Summary     string                        `json:"summary,omitempty"`
+	Description string                        `json:"description,omitempty"`
+	Parameters  []*Parameter                  `json:"parameters,omitempty"`
+	RequestBody *RequestBody                  `json:"requestBody,omitempty"`
+	Responses   map[string]*OperationResponse `json:"responses"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes a request body of the operation.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// OperationResponse describes a single response of the operation.
+type OperationResponse struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType provides schema for the media type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds reusable schemas.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is a (subset of) JSON Schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// WriteFile writes the specification (JSON) to the named file.
+func (doc *Document) WriteFile(name string) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, 0644)
+}
+
+// OpenAPI generates OpenAPI 3 specification of the registered routes. Request and
+// response models are described in provided mime types ("application/json" if
+// none).
+func OpenAPI(h Handler, info Info, mimeTypes ...string) *Document {
+	if len(mimeTypes) == 0 {
+		mimeTypes = []string{"application/json"}
+	}
+	gen := &schemaGenerator{schemas: make(map[string]*Schema), types: make(map[reflect.Type]string)}
+	doc := &Document{OpenAPI: OpenAPIVersion, Info: info, Paths: make(map[string]PathItem)}
+
+	for _, route := range h.Routes() {
+		// OPTIONS requests and routes accepting any method are not described
+		if route.Method == "*" || route.Method == http.MethodOptions {
+			continue
+		}
+		pattern := pathParamRegexp.ReplaceAllString(route.Path, "{$1}")
+		op := &Operation{
+			OperationID: operationID(route.Method, pattern),
+			Summary:     route.Action,
+			Responses:   map[string]*OperationResponse{},
+		}
+		if route.Module != "" {
+			op.Tags = []string{route.Module}
+		}
+		// path params
+		for _, match := range pathParamRegexp.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+			})
+		}
+		var desc *Description
+		if describer, ok := route.resource.(Describer); ok {
+			desc = describer.Describe(route.Action)
+		}
+		if desc == nil {
+			desc = &Description{}
+		}
+		if desc.Summary != "" {
+			op.Summary = desc.Summary
+		}
+		op.Description = desc.Description
+		for _, param := range desc.Query {
+			copied := *param
+			copied.In = "query"
+			if copied.Schema == nil {
+				copied.Schema = &Schema{Type: "string"}
+			}
+			op.Parameters = append(op.Parameters, &copied)
+		}
+		if desc.Request != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: make(map[string]*MediaType)}
+			schema := gen.schema(reflect.TypeOf(desc.Request))
+			for _, mime := range mimeTypes {
+				op.RequestBody.Content[mime] = &MediaType{Schema: schema}
+			}
+		}
+		// success response
+		status := http.StatusOK
+		if route.Method == http.MethodPost && (route.Action == "Post" || route.Action == "PostAll") {
+			status = http.StatusCreated
+		}
+		success := &OperationResponse{Description: http.StatusText(status)}
+		if desc.Response != nil {
+			schema := gen.schema(reflect.TypeOf(desc.Response))
+			success.Content = make(map[string]*MediaType)
+			for _, mime := range mimeTypes {
+				success.Content[mime] = &MediaType{Schema: schema}
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+		if route.Method == http.MethodPut || route.Method == http.MethodDelete {
+			op.Responses[strconv.Itoa(http.StatusNoContent)] = &OperationResponse{Description: http.StatusText(http.StatusNoContent)}
+		}
+		op.Responses["default"] = &OperationResponse{Description: "Error"}
+
+		if _, ok := doc.Paths[pattern]; !ok {
+			doc.Paths[pattern] = make(PathItem)
+		}
+		doc.Paths[pattern][strings.ToLower(route.Method)] = op
+	}
+	if len(gen.schemas) > 0 {
+		doc.Components = &Components{Schemas: gen.schemas}
+	}
+	return doc
+}
+
+// OpenAPIHandler returns an HTTP handler that sends OpenAPI specification of the
+// handler routes (it can be mounted with handler.HandleMethod at any path).
+func OpenAPIHandler(h Handler, info Info, mimeTypes ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(OpenAPI(h, info, mimeTypes...))
+	})
+}
+
+// operationID generates unique operation ID from HTTP method and path template.
+func operationID(method, pattern string) string {
+	parts := []string{strings.ToLower(method)}
+	for _, part := range strings.FieldsFunc(pattern, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "_")
+}
+
+// schemaGenerator reflects Go types into JSON schemas, struct types are stored as
+// reusable component schemas.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	types   map[reflect.Type]string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns JSON schema of provided type.
+func (gen *schemaGenerator) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &Schema{Type: "string", Format: "byte"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: gen.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: gen.schema(t.Elem())}
+	case reflect.Struct:
+		return gen.structSchema(t)
+	}
+	// interfaces, funcs, channels etc - any value
+	return &Schema{}
+}
+
+// structSchema stores struct schema in components and returns the reference.
+func (gen *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return gen.properties(t)
+	}
+	name, ok := gen.types[t]
+	if !ok {
+		name = t.Name()
+		// make schema name unique if types from different packages have equal names
+		for i := 2; gen.schemas[name] != nil; i++ {
+			name = t.Name() + strconv.Itoa(i)
+		}
+		gen.types[t] = name
+		// reserve the name before generating properties (recursive types)
+		gen.schemas[name] = &Schema{}
+		*gen.schemas[name] = *gen.properties(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// properties generates object schema of the struct fields (respecting json tags).
+func (gen *schemaGenerator) properties(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, opts = tag[:idx], tag[idx:]
+		}
+		// embedded structs without name are flattened
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := gen.properties(ft)
+				for key, value := range embedded.Properties {
+					schema.Properties[key] = value
+				}
+				schema.Required = append(schema.Required, embedded.Required...)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = gen.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
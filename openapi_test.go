@@ -0,0 +1,135 @@
+package lite
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	mw "github.com/tiny-go/middleware"
+)
+
+func Test_OpenAPI(t *testing.T) {
+	t.Run("Given an HTTP handler with registered modules", func(t *testing.T) {
+		driver.Default("application/json")
+		controller := &mockTypedController{Controller: mw.NewBaseController()}
+		module := NewBaseModule()
+		module.Register("pass", newPassController())
+		module.Register("typed", NewTypedController(controller, TypedGet(controller.Get), TypedPost(controller.Post)))
+		handler := NewHandler(WithLogger(DiscardLogger))
+		handler.Map("")
+		if err := handler.Use("test", module); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		handler.HandleMethod(http.MethodGet, "/openapi.json", OpenAPIHandler(handler, Info{Title: "Test", Version: "1.0"}))
+		doc := OpenAPI(handler, Info{Title: "Test", Version: "1.0"})
+		t.Run("should describe all the operations", func(t *testing.T) {
+			if doc.OpenAPI != OpenAPIVersion {
+				t.Errorf("unexpected version %q", doc.OpenAPI)
+			}
+			for pattern, methods := range map[string][]string{
+				"/test/pass":            {"get", "post", "patch", "put", "delete"},
+				"/test/pass/{pk}":       {"get", "post", "patch", "put", "delete"},
+				"/test/pass/{pk}/reset": {"post"},
+				"/test/typed/{pk}":      {"get", "post"},
+				"/openapi.json":         {"get"},
+			} {
+				item, ok := doc.Paths[pattern]
+				if !ok {
+					t.Errorf("path %q is missing", pattern)
+					continue
+				}
+				if len(item) != len(methods) {
+					t.Errorf("path %q was expected to have %d operations but had %d", pattern, len(methods), len(item))
+				}
+				for _, method := range methods {
+					if _, ok := item[method]; !ok {
+						t.Errorf("operation %q is missing for path %q", method, pattern)
+					}
+				}
+			}
+		})
+		t.Run("should describe typed controller models", func(t *testing.T) {
+			op := doc.Paths["/test/typed/{pk}"]["post"]
+			if op.RequestBody == nil || op.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/mockTypedInput" {
+				t.Fatalf("unexpected request body %+v", op.RequestBody)
+			}
+			if _, ok := op.Responses["201"]; !ok {
+				t.Error("created response is missing")
+			}
+			if doc.Paths["/test/typed/{pk}"]["get"].Summary != "Get typed model" {
+				t.Errorf("unexpected summary %q", doc.Paths["/test/typed/{pk}"]["get"].Summary)
+			}
+			expected := &Schema{Type: "object", Properties: map[string]*Schema{"foo": {Type: "string"}}, Required: []string{"foo"}}
+			if !reflect.DeepEqual(doc.Components.Schemas["mockTypedInput"], expected) {
+				t.Errorf("unexpected schema %+v", doc.Components.Schemas["mockTypedInput"])
+			}
+		})
+		t.Run("should serve the specification", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+			served := new(Document)
+			if err := json.NewDecoder(w.Body).Decode(served); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if served.Info.Title != "Test" || len(served.Paths) != len(doc.Paths) {
+				t.Errorf("unexpected document %+v", served)
+			}
+		})
+		t.Run("should export the specification to a file", func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "openapi")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer os.RemoveAll(dir)
+			name := filepath.Join(dir, "openapi.json")
+			if err := doc.WriteFile(name); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if data, err := ioutil.ReadFile(name); err != nil || len(data) == 0 {
+				t.Error("specification was not written")
+			}
+		})
+	})
+}
+
+func Test_Schema(t *testing.T) {
+	t.Run("Given JSON schema generator", func(t *testing.T) {
+		type node struct {
+			Name     string            `json:"name"`
+			Created  time.Time         `json:"created,omitempty"`
+			Children []*node           `json:"children,omitempty"`
+			Labels   map[string]string `json:"labels,omitempty"`
+			Ignored  int               `json:"-"`
+			private  int
+		}
+		gen := &schemaGenerator{schemas: make(map[string]*Schema), types: make(map[reflect.Type]string)}
+		t.Run("should reflect struct into component schema (including recursive types)", func(t *testing.T) {
+			ref := gen.schema(reflect.TypeOf(&node{}))
+			if ref.Ref != "#/components/schemas/node" {
+				t.Fatalf("unexpected reference %q", ref.Ref)
+			}
+			expected := &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"name":     {Type: "string"},
+					"created":  {Type: "string", Format: "date-time"},
+					"children": {Type: "array", Items: &Schema{Ref: "#/components/schemas/node"}},
+					"labels":   {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+				},
+				Required: []string{"name"},
+			}
+			if !reflect.DeepEqual(gen.schemas["node"], expected) {
+				data, _ := json.Marshal(gen.schemas["node"])
+				t.Errorf("unexpected schema %s", data)
+			}
+		})
+	})
+}
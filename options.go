@@ -0,0 +1,81 @@
+package lite
+
+import (
+	"github.com/tiny-go/codec"
+	mw "github.com/tiny-go/middleware"
+)
+
+// Option configures the handler.
+type Option func(*handler)
+
+// WithBasePath mounts all the routes (modules and custom routes) under provided
+// path prefix (for instance "/api/v1").
+func WithBasePath(prefix string) Option {
+	return func(h *handler) { h.basePath = prefix }
+}
+
+// WithCodecs sets the registry of request/response codecs used by the handler
+// (driver.Global() by default), modules and controllers may override it.
+func WithCodecs(codecs codec.Registry) Option {
+	return func(h *handler) { h.codecs = Codecs{codecs, codecs} }
+}
+
+// WithDefaultMiddleware replaces the default middleware applied to every route
+// first (panic recovery by default), for instance to use custom panic handling.
+// Codec negotiation, URI params and primary key validation are always applied.
+func WithDefaultMiddleware(middleware ...mw.Middleware) Option {
+	return func(h *handler) { h.stack = append([]mw.Middleware{}, middleware...) }
+}
+
+// WithMiddleware adds middleware applied to every route of the handler (after
+// the default middleware, so panics are recovered before it is called).
+func WithMiddleware(middleware ...mw.Middleware) Option {
+	return func(h *handler) { h.middleware = append(h.middleware, middleware...) }
+}
+
+// WithErrorRenderer sets the renderer used to send errors to the client
+// (TextRenderer by default).
+func WithErrorRenderer(renderer ErrorRenderer) Option {
+	return func(h *handler) { h.renderer = renderer }
+}
+
+// WithLogger sets the logger used for route registration, controller init and
+// dependency injection failures and access logs (DiscardLogger silences it).
+func WithLogger(logger Logger) Option {
+	return func(h *handler) {
+		if logger == nil {
+			logger = DiscardLogger
+		}
+		h.logger = logger
+	}
+}
+
+// WithAccessLog enables logging of every served request (method, path template,
+// module, controller, status, latency and response size), it is disabled by default.
+func WithAccessLog(enabled bool) Option {
+	return func(h *handler) { h.logRequests = enabled }
+}
+
+// WithStrictSlash defines whether trailing slash is significant (true by default).
+// If it is not, "/path/" is served by the route "/path" as well.
+func WithStrictSlash(strict bool) Option {
+	return func(h *handler) { h.strictSlash = strict }
+}
+
+// WithRedirectTrailingSlash makes the router redirect "/path/" to "/path" (and
+// vice versa) with 301 status code if only the other route exists.
+func WithRedirectTrailingSlash(redirect bool) Option {
+	return func(h *handler) { h.redirectSlash = redirect }
+}
+
+// WithBodyLimit limits the size of request bodies (larger requests are rejected
+// with 413), modules and controllers may override it with DecodingProvider.
+func WithBodyLimit(limit int64) Option {
+	return func(h *handler) { h.decoding.MaxBodySize = limit }
+}
+
+// WithStrictDecoding makes JSON decoding reject unknown fields and trailing data
+// after the first value with 400 (modules and controllers may override it).
+func WithStrictDecoding(strict bool) Option {
+	return func(h *handler) { h.decoding.Strict = &strict }
+}
@@ -0,0 +1,134 @@
+package lite
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	"github.com/tiny-go/codec/driver/xml"
+)
+
+func Test_Options(t *testing.T) {
+	driver.Default("application/json")
+	module := NewBaseModule()
+	module.Register("user", newPassController())
+
+	t.Run("Given handler with base path", func(t *testing.T) {
+		handler := NewHandler(WithLogger(DiscardLogger), WithBasePath("/api/v1"))
+		handler.Use("test", module)
+		handler.HandleFunc("/health/", func(http.ResponseWriter, *http.Request) {})
+		t.Run("should mount all the routes under base path", func(t *testing.T) {
+			for _, uri := range []string{"/api/v1/test/user/1", "/api/v1/health/"} {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, uri, nil))
+				if w.Code != http.StatusOK {
+					t.Errorf("status code of %q was expected to be %d but got %d", uri, http.StatusOK, w.Code)
+				}
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/user/1", nil))
+			if w.Code != http.StatusNotFound {
+				t.Errorf("status code was expected to be %d but got %d", http.StatusNotFound, w.Code)
+			}
+		})
+	})
+	t.Run("Given handler with custom codecs and middleware", func(t *testing.T) {
+		var calls int
+		handler := NewHandler(
+			WithLogger(DiscardLogger),
+			WithCodecs(driver.DummyRegistry{&xml.XML{}}),
+			WithMiddleware(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls++
+					next.ServeHTTP(w, r)
+				})
+			}),
+			WithErrorRenderer(ProblemRenderer),
+		)
+		handler.Use("test", module)
+		t.Run("should use handler codecs and call the middleware", func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/test/user/1", nil)
+			r.Header.Set("Accept", "application/xml")
+			r.Header.Set("Content-Type", "application/xml")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/xml" {
+				t.Errorf("unexpected response %d %q", w.Code, w.Header().Get("Content-Type"))
+			}
+			if calls != 1 {
+				t.Errorf("middleware was expected to be called once but was called %d times", calls)
+			}
+		})
+		t.Run("should render codec errors with handler renderer", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/user/1", nil))
+			if w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+			}
+		})
+	})
+	t.Run("Given handlers with different trailing slash behavior", func(t *testing.T) {
+		strict := NewHandler(WithLogger(DiscardLogger))
+		strict.Use("test", module)
+		loose := NewHandler(WithLogger(DiscardLogger), WithStrictSlash(false))
+		loose.Use("test", module)
+		redirect := NewHandler(WithLogger(DiscardLogger), WithRedirectTrailingSlash(true))
+		redirect.Use("test", module)
+		t.Run("should treat trailing slash accordingly", func(t *testing.T) {
+			for name, tc := range map[string]struct {
+				handler Handler
+				code    int
+			}{
+				"strict":   {strict, http.StatusNotFound},
+				"loose":    {loose, http.StatusOK},
+				"redirect": {redirect, http.StatusMovedPermanently},
+			} {
+				w := httptest.NewRecorder()
+				tc.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/user/1/", bytes.NewBuffer(nil)))
+				if w.Code != tc.code {
+					t.Errorf("%s: status code was expected to be %d but got %d", name, tc.code, w.Code)
+				}
+			}
+		})
+		t.Run("should not modify the request", func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/test/user/1/", nil)
+			loose.ServeHTTP(httptest.NewRecorder(), r)
+			if r.URL.Path != "/test/user/1/" {
+				t.Errorf("request path was expected to be unchanged but got %q", r.URL.Path)
+			}
+		})
+		t.Run("should serve explicit routes with trailing slash", func(t *testing.T) {
+			loose.HandleFunc("/health/", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+			w := httptest.NewRecorder()
+			loose.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/", nil))
+			if w.Code != http.StatusNoContent {
+				t.Errorf("status code was expected to be %d but got %d", http.StatusNoContent, w.Code)
+			}
+		})
+	})
+	t.Run("Given handler with replaced default middleware", func(t *testing.T) {
+		handler := NewHandler(WithLogger(DiscardLogger), WithDefaultMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer func() {
+					if recover() != nil {
+						w.WriteHeader(http.StatusTeapot)
+					}
+				}()
+				next.ServeHTTP(w, r)
+			})
+		}))
+		handler.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) { panic("oops") })
+		t.Run("should use it instead of the default one", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+			if w.Code != http.StatusTeapot {
+				t.Errorf("status code was expected to be %d but got %d", http.StatusTeapot, w.Code)
+			}
+		})
+	})
+}
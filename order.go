@@ -0,0 +1,68 @@
+package lite
+
+import "sort"
+
+// Order defines the order in which modules and controllers are iterated (and so
+// the order of the routes).
+type Order int
+
+const (
+	// RegistrationOrder iterates in order of registration (default).
+	RegistrationOrder Order = iota
+	// AliasOrder iterates in alphabetical order of aliases.
+	AliasOrder
+	// PriorityOrder iterates by priority (higher first, see Prioritizer), items
+	// with the same priority are iterated in order of registration.
+	PriorityOrder
+)
+
+// Prioritizer can be implemented by the module or controller in order to define
+// its position if PriorityOrder is used (zero by default).
+type Prioritizer interface {
+	Priority() int
+}
+
+// sequence keeps registration order of the aliases.
+type sequence struct {
+	next int
+	seq  map[string]int
+}
+
+// add assigns the next sequence number to the alias.
+func (s *sequence) add(alias string) {
+	if s.seq == nil {
+		s.seq = make(map[string]int)
+	}
+	s.seq[alias] = s.next
+	s.next++
+}
+
+// remove removes the alias.
+func (s *sequence) remove(alias string) { delete(s.seq, alias) }
+
+// sort orders the aliases, item func returns the module or controller by alias.
+func (s *sequence) sort(aliases []string, order Order, item func(alias string) interface{}) []string {
+	sort.SliceStable(aliases, func(i, j int) bool {
+		switch order {
+		case AliasOrder:
+			return aliases[i] < aliases[j]
+		case PriorityOrder:
+			if pi, pj := priority(item(aliases[i])), priority(item(aliases[j])); pi != pj {
+				return pi > pj
+			}
+		}
+		return s.seq[aliases[i]] < s.seq[aliases[j]]
+	})
+	return aliases
+}
+
+// priority returns the priority of the module or controller.
+func priority(v interface{}) int {
+	if resource, ok := v.(Controller); ok && resource != nil {
+		v = unwrap(resource)
+	}
+	if p, ok := v.(Prioritizer); ok {
+		return p.Priority()
+	}
+	return 0
+}
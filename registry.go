@@ -6,8 +6,10 @@ import (
 )
 
 var (
-	modulesMu sync.RWMutex
-	modules   = make(map[string]Module)
+	modulesMu    sync.RWMutex
+	modules      = make(map[string]Module)
+	modulesSeq   sequence
+	modulesOrder Order
 )
 
 // Register makes module available with provided alias.
@@ -19,16 +21,30 @@ func Register(alias string, module Module) {
 		panic(fmt.Sprintf(`alias %q already in use`, alias))
 	}
 	modules[alias] = module
+	modulesSeq.add(alias)
 }
 
-// Modules iterates all registered modules applying provided func.
+// Modules iterates all registered modules applying provided func (in order of
+// registration unless other order is set).
 func Modules(f func(alias string, module Module) bool) {
 	modulesMu.Lock()
 	defer modulesMu.Unlock()
 
-	for alias, resource := range modules {
-		if !f(alias, resource) {
+	aliases := make([]string, 0, len(modules))
+	for alias := range modules {
+		aliases = append(aliases, alias)
+	}
+	for _, alias := range modulesSeq.sort(aliases, modulesOrder, func(alias string) interface{} { return modules[alias] }) {
+		if !f(alias, modules[alias]) {
 			break
 		}
 	}
 }
+
+// SetModulesOrder sets the order in which Modules iterates registered modules.
+func SetModulesOrder(order Order) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	modulesOrder = order
+}
@@ -60,3 +60,30 @@ func Test_Modules(t *testing.T) {
 		})
 	})
 }
+
+func Test_ModulesOrder(t *testing.T) {
+	t.Run("Given global module registry", func(t *testing.T) {
+		defer func() {
+			modules = make(map[string]Module)
+			SetModulesOrder(RegistrationOrder)
+		}()
+		Register("moduleB", NewBaseModule())
+		Register("moduleC", NewBaseModule())
+		Register("moduleA", NewBaseModule())
+		list := func() (aliases string) {
+			Modules(func(alias string, _ Module) bool { aliases += alias[len(alias)-1:]; return true })
+			return aliases
+		}
+		t.Run("Modules should iterate modules in order of registration by default", func(t *testing.T) {
+			if out := list(); out != "BCA" {
+				t.Errorf("modules were iterated in unexpected order %q", out)
+			}
+		})
+		t.Run("Modules should iterate modules in alphabetical order if required", func(t *testing.T) {
+			SetModulesOrder(AliasOrder)
+			if out := list(); out != "ABC" {
+				t.Errorf("modules were iterated in unexpected order %q", out)
+			}
+		})
+	})
+}
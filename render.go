@@ -0,0 +1,150 @@
+package lite
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/errors"
+	mw "github.com/tiny-go/middleware"
+)
+
+// RequestIDHeader is the name of the header containing unique request ID.
+const RequestIDHeader = "X-Request-ID"
+
+type rendererKey struct{}
+
+var _ errors.Error = &Problem{}
+
+// ErrorRenderer is responsible for sending errors (or recovered panics) to the
+// client.
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, err interface{})
+
+// TextRenderer sends the error as a plain text (this is a default renderer).
+func TextRenderer(w http.ResponseWriter, _ *http.Request, err interface{}) { errors.Send(w, err) }
+
+// RenderError sends the error to the client using error renderer of the handler
+// (if available in request context) or TextRenderer otherwise.
+func RenderError(w http.ResponseWriter, r *http.Request, err interface{}) {
+	if render, ok := r.Context().Value(rendererKey{}).(ErrorRenderer); ok && render != nil {
+		render(w, r, err)
+		return
+	}
+	TextRenderer(w, r, err)
+}
+
+// Problem represents RFC 7807 problem details. It can also be returned by the
+// controller as an error in order to provide extension fields (for instance a list
+// of validation errors).
+type Problem struct {
+	XMLName   xml.Name `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type      string   `json:"type,omitempty" xml:"type,omitempty"`
+	Title     string   `json:"title,omitempty" xml:"title,omitempty"`
+	Status    int      `json:"status,omitempty" xml:"status,omitempty"`
+	Detail    string   `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance  string   `json:"instance,omitempty" xml:"instance,omitempty"`
+	RequestID string   `json:"requestId,omitempty" xml:"requestId,omitempty"`
+	// Extensions contains additional members of the problem (their names should
+	// not match the names of standard members).
+	Extensions map[string]interface{} `json:"-" xml:"-"`
+}
+
+// Error returns problem details (or title if details are empty).
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// Code returns HTTP status code.
+func (p *Problem) Code() int { return p.Status }
+
+// MarshalJSON encodes problem to JSON adding extension members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	data, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	ext, err := json.Marshal(p.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(data, []byte("{}")) {
+		return ext, nil
+	}
+	// merge both objects
+	return append(append(data[:len(data)-1], ','), ext[1:]...), nil
+}
+
+// newProblem converts the error (or any value recovered from panic) to Problem,
+// messages of the errors without status code are not exposed.
+func newProblem(err interface{}) *Problem {
+	var problem *Problem
+	switch e := err.(type) {
+	case *Problem:
+		copied := *e
+		problem = &copied
+	case errors.Error:
+		problem = &Problem{Status: e.Code(), Detail: e.Error()}
+	default:
+		problem = &Problem{Status: http.StatusInternalServerError}
+	}
+	if problem.Status == 0 {
+		problem.Status = http.StatusInternalServerError
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	return problem
+}
+
+// ProblemRenderer sends the error as RFC 7807 problem details using response codec
+// ("application/problem+json" is used if response codec is not available).
+func ProblemRenderer(w http.ResponseWriter, r *http.Request, err interface{}) {
+	if err == nil {
+		return
+	}
+	problem := newProblem(err)
+	problem.Instance = r.URL.Path
+	problem.RequestID = RequestID(w, r)
+
+	var encoder codec.Encoder
+	mimeType := "application/problem+json"
+	switch resCodec := mw.ResponseCodecFromContext(r.Context()); {
+	case resCodec == nil:
+		encoder = json.NewEncoder(w)
+	case resCodec.MimeType() == "application/json":
+		encoder = resCodec.Encoder(w)
+	case resCodec.MimeType() == "application/xml":
+		mimeType = "application/problem+xml"
+		encoder = resCodec.Encoder(w)
+	default:
+		mimeType = resCodec.MimeType()
+		encoder = resCodec.Encoder(w)
+	}
+	w.Header().Set("Content-Type", mimeType)
+	w.WriteHeader(problem.Status)
+	encoder.Encode(problem)
+}
+
+// RequestID returns request ID provided by the client (or generates a new one)
+// and sets it to the response header.
+func RequestID(w http.ResponseWriter, r *http.Request) string {
+	id := r.Header.Get(RequestIDHeader)
+	if id == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	w.Header().Set(RequestIDHeader, id)
+	return id
+}
@@ -0,0 +1,96 @@
+package lite
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+)
+
+func Test_Problem(t *testing.T) {
+	t.Run("Given RFC 7807 problem details", func(t *testing.T) {
+		t.Run("should be encoded to JSON with extension members", func(t *testing.T) {
+			data, err := json.Marshal(&Problem{
+				Status:     http.StatusUnprocessableEntity,
+				Title:      "Validation failed",
+				Extensions: map[string]interface{}{"errors": []string{"email is required"}},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(data) != "{\"title\":\"Validation failed\",\"status\":422,\"errors\":[\"email is required\"]}" {
+				t.Errorf("unexpected output %q", data)
+			}
+		})
+		t.Run("should not expose messages of errors without status code", func(t *testing.T) {
+			problem := newProblem(errors.New("secret"))
+			if problem.Status != http.StatusInternalServerError || problem.Detail != "" {
+				t.Errorf("unexpected problem %+v", problem)
+			}
+		})
+	})
+}
+
+func Test_ProblemRenderer(t *testing.T) {
+	t.Run("Given an HTTP handler with problem details renderer", func(t *testing.T) {
+		driver.Default("application/json")
+		module := NewBaseModule()
+		module.Register("fail", newFailController())
+		handler := NewHandler(WithLogger(DiscardLogger))
+		handler.SetErrorRenderer(ProblemRenderer)
+		handler.Use("test", module)
+		handler.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) { panic("boom") })
+
+		type testCase struct {
+			title string
+			path  string
+			code  int
+			body  string
+		}
+		testCases := []testCase{
+			{
+				title: "controller error should be rendered as problem details",
+				path:  "/test/fail/abcd",
+				code:  http.StatusBadRequest,
+				body:  "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"single GET error\",\"instance\":\"/test/fail/abcd\",\"requestId\":\"1234\"}\n",
+			},
+			{
+				title: "recovered panic should be rendered as problem details",
+				path:  "/panic",
+				code:  http.StatusInternalServerError,
+				body:  "{\"type\":\"about:blank\",\"title\":\"Internal Server Error\",\"status\":500,\"instance\":\"/panic\",\"requestId\":\"1234\"}\n",
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.title, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+				r.Header.Set(RequestIDHeader, "1234")
+				handler.ServeHTTP(w, r)
+				if w.Code != tc.code {
+					t.Errorf("status code %d was expected to be %d", w.Code, tc.code)
+				}
+				if w.Header().Get("Content-Type") != "application/problem+json" {
+					t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+				}
+				if w.Header().Get(RequestIDHeader) != "1234" {
+					t.Errorf("unexpected request ID %q", w.Header().Get(RequestIDHeader))
+				}
+				if w.Body.String() != tc.body {
+					t.Errorf("the output %q was expected to be %q", w.Body.String(), tc.body)
+				}
+			})
+		}
+		t.Run("should generate request ID if not provided", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/fail/abcd", nil))
+			if len(w.Header().Get(RequestIDHeader)) != 32 {
+				t.Errorf("unexpected request ID %q", w.Header().Get(RequestIDHeader))
+			}
+		})
+	})
+}
@@ -0,0 +1,89 @@
+package lite
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// Response allows controller actions to control the status code, headers and
+// body of the response (instead of using the defaults of the action).
+type Response struct {
+	// Status is an HTTP status code (default one is used if zero).
+	Status int
+	// Header contains response headers (overriding the default ones).
+	Header http.Header
+	// Body is encoded with response codec (nothing is sent if nil), Blob is
+	// written as is.
+	Body interface{}
+}
+
+// Blob is a binary response (for instance a file download) that is written as is
+// bypassing the codec. Range requests and conditional requests (If-Modified-Since
+// etc) are supported.
+type Blob struct {
+	// Name is a file name used in Content-Disposition header (and to detect the
+	// content type by extension), it can be empty.
+	Name string
+	// ContentType of the content (detected by name or by the content if empty).
+	ContentType string
+	// ModTime is the modification time of the content (used by conditional
+	// requests if not zero).
+	ModTime time.Time
+	// Inline makes the browser display the content instead of downloading it.
+	Inline bool
+	// Content is the data (closed after the response if it implements io.Closer).
+	Content io.ReadSeeker
+}
+
+// NewBlob creates a Blob with provided data.
+func NewBlob(name string, data []byte) *Blob {
+	return &Blob{Name: name, Content: bytes.NewReader(data)}
+}
+
+// serve writes the content to the client with provided status code (ignored if
+// the request is conditional or partial).
+func (b *Blob) serve(w http.ResponseWriter, r *http.Request, code int) {
+	if closer, ok := b.Content.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if b.ContentType != "" {
+		w.Header().Set("Content-Type", b.ContentType)
+	}
+	disposition := "attachment"
+	if b.Inline {
+		disposition = "inline"
+	}
+	params := map[string]string{}
+	if b.Name != "" {
+		params["filename"] = b.Name
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, params))
+	if code != http.StatusOK && r.Header.Get("Range") == "" {
+		// custom status code (for instance 201) of a regular response
+		w = &statusOverride{ResponseWriter: w, code: code}
+	}
+	http.ServeContent(w, r, b.Name, b.ModTime, b.Content)
+}
+
+// statusOverride replaces successful status code written by http.ServeContent.
+type statusOverride struct {
+	http.ResponseWriter
+	code int
+}
+
+// WriteHeader writes custom status code instead of 200.
+func (so *statusOverride) WriteHeader(code int) {
+	if code == http.StatusOK {
+		code = so.code
+	}
+	so.ResponseWriter.WriteHeader(code)
+}
+
+// Identifier should be able to provide its primary key, it is used to build the
+// "Location" header of the created model.
+type Identifier interface {
+	PrimaryKey() string
+}
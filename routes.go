@@ -0,0 +1,34 @@
+package lite
+
+import "net/http"
+
+// Route describes a single route registered by the handler.
+type Route struct {
+	// Method is an HTTP method of the route ("*" if route accepts any method).
+	Method string `json:"method" xml:"Method"`
+	// Path is a path template of the route.
+	Path string `json:"path" xml:"Path"`
+	// Module is an alias of the module (empty for custom routes).
+	Module string `json:"module,omitempty" xml:"Module,omitempty"`
+	// Controller is an alias of the controller (empty for custom routes).
+	Controller string `json:"controller,omitempty" xml:"Controller,omitempty"`
+	// Type is a type name of the controller.
+	Type string `json:"type,omitempty" xml:"Type,omitempty"`
+	// Action is a name of the controller action (method name, custom action name
+	// or "Options").
+	Action string `json:"action,omitempty" xml:"Action,omitempty"`
+	// Middleware is a number of middleware funcs wrapping the action (all the
+	// controller middleware for HTTP method is counted as one).
+	Middleware int `json:"middleware" xml:"Middleware"`
+	// resource is the controller (used to describe the action)
+	resource Controller
+}
+
+// RoutesHandler returns an HTTP handler that sends the list of registered routes
+// using response codec (it can be mounted with handler.HandleMethod, for instance
+// as "/_routes").
+func RoutesHandler(h Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		send(w, r, http.StatusOK, h.Routes())
+	})
+}
@@ -0,0 +1,56 @@
+package lite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+)
+
+func Test_Routes(t *testing.T) {
+	t.Run("Given an HTTP handler with registered module", func(t *testing.T) {
+		driver.Default("application/json")
+		module := NewBaseModule()
+		module.Register("pass", newPassController())
+		handler := NewHandler(WithLogger(DiscardLogger))
+		handler.Use("test", module)
+		handler.HandleMethod(http.MethodGet, "/_routes", RoutesHandler(handler))
+		t.Run("should return the list of registered routes", func(t *testing.T) {
+			routes := handler.Routes()
+			if len(routes) != 17 {
+				t.Fatalf("the number of routes %d was expected to be 17", len(routes))
+			}
+			expected := Route{
+				Method:     http.MethodGet,
+				Path:       "/test/pass/{pk}",
+				Module:     "test",
+				Controller: "pass",
+				Type:       "*lite.mockController",
+				Action:     "Get",
+				Middleware: 5,
+			}
+			// controller reference is not compared
+			route := routes[5]
+			route.resource = nil
+			if !reflect.DeepEqual(route, expected) {
+				t.Errorf("route %+v was expected to be %+v", route, expected)
+			}
+			if last := routes[len(routes)-1]; last.Path != "/_routes" || last.Module != "" {
+				t.Errorf("unexpected custom route %+v", last)
+			}
+		})
+		t.Run("should send the list of routes to the client", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_routes", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("status code %d was expected to be %d", w.Code, http.StatusOK)
+			}
+			if w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+			}
+		})
+	})
+}
@@ -0,0 +1,276 @@
+package lite
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/errors"
+	mw "github.com/tiny-go/middleware"
+)
+
+// streamFlushInterval defines how often streamed data is flushed to the client.
+var streamFlushInterval = 100 * time.Millisecond
+
+// StreamCodec is a codec that is able to write the items of the stream one by one
+// (for instance NDJSON or CSV), see codec/ndjson and codec/csv packages.
+type StreamCodec interface {
+	codec.Codec
+	StreamEncoder(w io.Writer) codec.Encoder
+}
+
+// streamPlural handles plural GET request on provided resource streaming the
+// models if response codec supports it. Otherwise the fallback (regular GetAll
+// action) is called if available or streamed models are sent as a list. If the
+// stream fails after it has been started the response is aborted.
+func streamPlural(controller StreamGetter, fallback PluralGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resCodec, ok := mw.ResponseCodecFromContext(r.Context()).(StreamCodec)
+		if !ok {
+			if fallback != nil {
+				getPlural(fallback)(w, r)
+				return
+			}
+			items := []interface{}{}
+			if err := controller.StreamAll(r.Context(), r.URL.Query(), func(item interface{}) error {
+				items = append(items, item)
+				return nil
+			}); err != nil {
+				RenderError(w, r, err)
+				return
+			}
+			send(w, r, http.StatusOK, items)
+			return
+		}
+		w.Header().Set("Content-Type", resCodec.MimeType())
+		dw := &deferredWriter{ResponseWriter: w, code: http.StatusOK}
+		enc := resCodec.StreamEncoder(dw)
+		flusher, _ := w.(http.Flusher)
+		var flushed time.Time
+		// call the controller action
+		err := controller.StreamAll(r.Context(), r.URL.Query(), func(item interface{}) error {
+			// stop if client has gone away
+			if err := r.Context().Err(); err != nil {
+				return err
+			}
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+			// flush the first item immediately and then periodically
+			if flusher != nil && (flushed.IsZero() || time.Since(flushed) >= streamFlushInterval) {
+				flusher.Flush()
+				flushed = time.Now()
+			}
+			return nil
+		})
+		if err != nil && !dw.written {
+			w.Header().Del("Content-Type")
+			RenderError(w, r, err)
+			return
+		}
+		if err != nil {
+			// the response cannot be changed once streaming has started, so the
+			// connection is aborted to let the client know the stream is incomplete
+			loggerFromContext(r.Context()).Error("stream aborted", "path", r.URL.Path, "error", err)
+			panic(http.ErrAbortHandler)
+		}
+		dw.writeHeader()
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// StreamDecoder can be implemented by the codec in order to decode the items of
+// the stream one by one (decoder should return io.EOF at the end of the stream).
+// JSON request bodies (arrays or sequences of values) are always supported.
+type StreamDecoder interface {
+	StreamDecoder(r io.Reader) codec.Decoder
+}
+
+// BulkResult is a summary of item-streaming bulk request.
+type BulkResult struct {
+	XMLName xml.Name `json:"-" xml:"result"`
+	// Accepted is a number of successfully processed items.
+	Accepted int `json:"accepted" xml:"accepted"`
+	// Rejected contains the errors of the items that have not been processed.
+	Rejected []BulkError `json:"rejected" xml:"rejected"`
+}
+
+// BulkError describes rejected item of the bulk request.
+type BulkError struct {
+	// Index is a zero based position of the item in the request body.
+	Index int `json:"index" xml:"index"`
+	// Code is HTTP status code of the error.
+	Code int `json:"code" xml:"code"`
+	// Error is the error message (messages of the errors without status code
+	// are not exposed).
+	Error string `json:"error" xml:"error"`
+}
+
+// streamItems handles bulk request decoding the items of the request body one by
+// one and calling the action for each of them. Summary of the request is sent to
+// the client. If request codec does not support streaming the fallback (regular
+// bulk action) is called if available.
+func streamItems(action func(*http.Request, func(interface{}) error) error, fallback http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dec := itemDecoder(mw.RequestCodecFromContext(r.Context()), r.Body, decodingFromContext(r.Context()).strict())
+		if dec == nil {
+			if fallback != nil {
+				fallback(w, r)
+				return
+			}
+			RenderError(w, r, errors.NewStatusError(http.StatusUnsupportedMediaType,
+				fmt.Errorf("streaming is not supported for %q", r.Header.Get("Content-Type"))))
+			return
+		}
+		result := &BulkResult{Rejected: []BulkError{}}
+	items:
+		for index := 0; r.Context().Err() == nil; index++ {
+			var decoded bool
+			var decodeErr error
+			err := action(r, func(v interface{}) error {
+				decoded = true
+				decodeErr = dec.Decode(v)
+				return decodeErr
+			})
+			if decodeErr == io.EOF {
+				break
+			}
+			if !decoded {
+				// the stream cannot be continued if the item has not been read
+				if err == nil {
+					err = fmt.Errorf("item %d has not been decoded", index)
+				}
+				RenderError(w, r, err)
+				return
+			}
+			var typeErr *json.UnmarshalTypeError
+			var itemErr *itemError
+			switch _, failed := decodeErr.(errors.Error); {
+			case decodeErr == nil:
+			case failed:
+				// the body cannot be read any further (for instance it is too large)
+				RenderError(w, r, decodeErr)
+				return
+			case stderrors.As(decodeErr, &typeErr), stderrors.As(decodeErr, &itemErr):
+				// the item has been read, so the next one can still be decoded
+				err = errors.NewStatusError(http.StatusBadRequest, decodeErr)
+			case index == 0:
+				// malformed request body
+				RenderError(w, r, errors.NewStatusError(http.StatusBadRequest, decodeErr))
+				return
+			default:
+				// the rest of the body cannot be decoded
+				result.Rejected = append(result.Rejected, bulkError(index, errors.NewStatusError(http.StatusBadRequest, decodeErr)))
+				break items
+			}
+			if err != nil {
+				result.Rejected = append(result.Rejected, bulkError(index, err))
+				continue
+			}
+			result.Accepted++
+		}
+		if err := r.Context().Err(); err != nil {
+			RenderError(w, r, err)
+			return
+		}
+		send(w, r, http.StatusOK, result)
+	}
+}
+
+// bulkError converts the error of the item to BulkError.
+func bulkError(index int, err error) BulkError {
+	problem := newProblem(err)
+	if _, ok := err.(errors.Error); ok {
+		return BulkError{Index: index, Code: problem.Status, Error: err.Error()}
+	}
+	return BulkError{Index: index, Code: problem.Status, Error: problem.Title}
+}
+
+// itemDecoder returns the decoder of the stream items (nil if request codec does
+// not support streaming), strict JSON decoder rejects unknown fields.
+func itemDecoder(reqCodec codec.Codec, body io.Reader, strict bool) codec.Decoder {
+	if reqCodec == nil {
+		return nil
+	}
+	if sd, ok := reqCodec.(StreamDecoder); ok {
+		return sd.StreamDecoder(body)
+	}
+	if mimeType := reqCodec.MimeType(); mimeType == "application/json" || strings.HasSuffix(mimeType, "+json") {
+		return &jsonItems{r: bufio.NewReader(body), strict: strict}
+	}
+	return nil
+}
+
+// itemError is returned if the item has been read from the stream but cannot be
+// decoded into the value (so the next item can still be decoded).
+type itemError struct{ err error }
+
+// Error implements error interface.
+func (e *itemError) Error() string { return e.err.Error() }
+
+// Unwrap returns the original error.
+func (e *itemError) Unwrap() error { return e.err }
+
+// jsonItems decodes the items of JSON array (or a sequence of JSON values) one
+// by one, strict decoding rejects the items with unknown fields.
+type jsonItems struct {
+	r      *bufio.Reader
+	dec    *json.Decoder
+	array  bool
+	strict bool
+}
+
+// Decode decodes the next item (io.EOF is returned at the end of the stream).
+func (j *jsonItems) Decode(v interface{}) error {
+	if j.dec == nil {
+		// check if the body is an array skipping leading whitespace
+		for {
+			b, err := j.r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+				j.r.UnreadByte()
+				j.array = b == '['
+				break
+			}
+		}
+		j.dec = json.NewDecoder(j.r)
+		if j.array {
+			if _, err := j.dec.Token(); err != nil {
+				return err
+			}
+		}
+	}
+	if j.array && !j.dec.More() {
+		// read the closing bracket
+		if _, err := j.dec.Token(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	if !j.strict {
+		return j.dec.Decode(v)
+	}
+	// read the item first, so an unknown field does not break the stream
+	var raw json.RawMessage
+	if err := j.dec.Decode(&raw); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &itemError{err}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package lite
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	"github.com/tiny-go/codec/driver/json"
+	"github.com/tiny-go/codec/driver/xml"
+	"github.com/tiny-go/errors"
+	"github.com/tiny-go/lite/codec/csv"
+	"github.com/tiny-go/lite/codec/ndjson"
+	mw "github.com/tiny-go/middleware"
+)
+
+func Test_StreamGetter(t *testing.T) {
+	t.Run("Given a handler with streaming controllers", func(t *testing.T) {
+		driver.Default("application/json")
+		module := NewBaseModule()
+		module.Register("models", &mockStreamController{Controller: mw.NewBaseController(), Count: 3})
+		module.Register("empty", &mockStreamController{Controller: mw.NewBaseController()})
+		module.Register("broken", &mockStreamController{Controller: mw.NewBaseController(), Err: errors.BadRequest("stream error")})
+		module.Register("partial", &mockStreamController{Controller: mw.NewBaseController(), Count: 1, Err: errors.BadRequest("stream error")})
+		handler := NewHandler(WithLogger(DiscardLogger), WithCodecs(CodecList{&json.JSON{}, &ndjson.NDJSON{}, &csv.CSV{}}))
+		handler.Use("test", module)
+
+		cases := []struct {
+			title  string
+			uri    string
+			accept string
+			code   int
+			body   string
+		}{
+			{"should stream NDJSON", "/test/models", "application/x-ndjson", http.StatusOK, "{\"id\":\"0\"}\n{\"id\":\"1\"}\n{\"id\":\"2\"}\n"},
+			{"should stream CSV with header", "/test/models", "text/csv", http.StatusOK, "id\n0\n1\n2\n"},
+			{"should send a list with regular codec", "/test/models", "application/json", http.StatusOK, "[{\"id\":\"0\"},{\"id\":\"1\"},{\"id\":\"2\"}]\n"},
+			{"should send an empty list with regular codec", "/test/empty", "application/json", http.StatusOK, "[]\n"},
+			{"should send an empty stream", "/test/empty", "application/x-ndjson", http.StatusOK, ""},
+			{"should send an error if nothing has been streamed", "/test/broken", "application/x-ndjson", http.StatusBadRequest, "stream error\n"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.title, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, tc.uri, nil)
+				r.Header.Set("Accept", tc.accept)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tc.code {
+					t.Errorf("status code %d was expected to be %d", w.Code, tc.code)
+				}
+				if w.Body.String() != tc.body {
+					t.Errorf("the output %q was expected to be %q", w.Body.String(), tc.body)
+				}
+				if tc.code == http.StatusOK && w.Header().Get("Content-Type") != tc.accept {
+					t.Errorf("content type %q was expected to be %q", w.Header().Get("Content-Type"), tc.accept)
+				}
+			})
+		}
+		t.Run("should flush streamed data", func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/test/models", nil)
+			r.Header.Set("Accept", "application/x-ndjson")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if !w.Flushed {
+				t.Error("response was expected to be flushed")
+			}
+		})
+		t.Run("should abort the response on error once streaming has started", func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			handler := NewHandler(WithLogger(StdLogger(log.New(buf, "", 0))), WithCodecs(CodecList{&ndjson.NDJSON{}}))
+			handler.Use("test", module)
+			r := httptest.NewRequest(http.MethodGet, "/test/partial", nil)
+			w := httptest.NewRecorder()
+			defer func() {
+				if rec := recover(); rec != http.ErrAbortHandler {
+					t.Errorf("the handler was expected to abort but got %v", rec)
+				}
+				if w.Body.String() != "{\"id\":\"0\"}\n" {
+					t.Errorf("the output %q was expected to contain the first item", w.Body.String())
+				}
+				if !strings.Contains(buf.String(), "stream error") {
+					t.Errorf("the error was expected to be logged but got %q", buf.String())
+				}
+			}()
+			handler.ServeHTTP(w, r)
+		})
+		t.Run("should stop streaming if client has gone away", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			r := httptest.NewRequest(http.MethodGet, "/test/models", nil).WithContext(ctx)
+			r.Header.Set("Accept", "application/x-ndjson")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if strings.Contains(w.Body.String(), "id") {
+				t.Errorf("nothing was expected to be streamed but got %q", w.Body.String())
+			}
+		})
+	})
+}
+
+func Test_StreamCodecs(t *testing.T) {
+	t.Run("Given CSV codec", func(t *testing.T) {
+		c := &csv.CSV{}
+		t.Run("should encode maps, structs and string slices", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			enc := c.StreamEncoder(w.Body)
+			enc.Encode(map[string]interface{}{"b": 2, "a": "x,y"})
+			enc.Encode(struct {
+				A string `json:"a"`
+				B int    `json:"b"`
+				C bool   `json:"-"`
+			}{"z", 3, true})
+			enc.Encode([]string{"1", "2"})
+			if expected := "a,b\n\"x,y\",2\nz,3\n1,2\n"; w.Body.String() != expected {
+				t.Errorf("the output %q was expected to be %q", w.Body.String(), expected)
+			}
+		})
+		t.Run("should decode rows by header", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c.Encoder(w.Body).Encode([]mockModel{{"1"}, {"2"}})
+			var rows []map[string]string
+			if err := c.Decoder(w.Body).Decode(&rows); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(rows) != 2 || rows[1]["id"] != "2" {
+				t.Errorf("unexpected rows %v", rows)
+			}
+		})
+	})
+	t.Run("Given NDJSON codec", func(t *testing.T) {
+		c := &ndjson.NDJSON{}
+		t.Run("should decode all the lines into a slice", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c.Encoder(w.Body).Encode([]mockModel{{"1"}, {"2"}})
+			var models []mockModel
+			if err := c.Decoder(w.Body).Decode(&models); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(models) != 2 || models[1].ID != "2" {
+				t.Errorf("unexpected models %v", models)
+			}
+		})
+	})
+}
+
+func Test_StreamItems(t *testing.T) {
+	t.Run("Given a handler with item-streaming controller", func(t *testing.T) {
+		controller := &mockBulkController{Controller: mw.NewBaseController()}
+		module := NewBaseModule()
+		module.Register("models", controller)
+		module.Register("failing", &mockBulkController{Controller: mw.NewBaseController(), Err: errors.Forbidden("not allowed")})
+		handler := NewHandler(WithLogger(DiscardLogger), WithCodecs(CodecList{&json.JSON{}, &ndjson.NDJSON{}, &xml.XML{}}))
+		handler.Use("test", module)
+
+		cases := []struct {
+			title       string
+			method      string
+			contentType string
+			body        string
+			code        int
+			response    string
+			stored      []string
+		}{
+			{
+				"should process JSON array item by item", http.MethodPost, "application/json",
+				` [{"id":"1"}, {"id":""}, {"id":2}, {"id":"3"}] `, http.StatusOK,
+				`{"accepted":2,"rejected":[{"index":1,"code":400,"error":"id is required"},{"index":2,"code":400,"error":"json: cannot unmarshal number into Go struct field mockModel.id of type string"}]}` + "\n",
+				[]string{"1", "3"},
+			},
+			{
+				"should process NDJSON item by item", http.MethodPut, "application/x-ndjson",
+				"{\"id\":\"1\"}\n{\"id\":\"2\"}\n", http.StatusOK,
+				`{"accepted":2,"rejected":[]}` + "\n",
+				[]string{"1", "2"},
+			},
+			{
+				"should process a sequence of JSON values", http.MethodPost, "application/json",
+				`{"id":"1"} {"id":"2"}`, http.StatusOK,
+				`{"accepted":2,"rejected":[]}` + "\n",
+				[]string{"1", "2"},
+			},
+			{
+				"should accept an empty array", http.MethodPost, "application/json",
+				`[]`, http.StatusOK,
+				`{"accepted":0,"rejected":[]}` + "\n",
+				nil,
+			},
+			{
+				"should stop processing malformed body", http.MethodPost, "application/json",
+				`[{"id":"1"}, {"id":`, http.StatusOK,
+				`{"accepted":1,"rejected":[{"index":1,"code":400,"error":"unexpected EOF"}]}` + "\n",
+				[]string{"1"},
+			},
+			{
+				"should reject malformed body", http.MethodPost, "application/json",
+				`[x]`, http.StatusBadRequest,
+				"invalid character 'x' looking for beginning of value\n",
+				nil,
+			},
+			{
+				"should reject unsupported content type", http.MethodPost, "application/xml",
+				`<id>1</id>`, http.StatusUnsupportedMediaType,
+				"streaming is not supported for \"application/xml\"\n",
+				nil,
+			},
+		}
+		for _, tc := range cases {
+			t.Run(tc.title, func(t *testing.T) {
+				controller.Stored = nil
+				r := httptest.NewRequest(tc.method, "/test/models", strings.NewReader(tc.body))
+				r.Header.Set("Content-Type", tc.contentType)
+				r.Header.Set("Accept", "application/json")
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tc.code {
+					t.Errorf("status code %d was expected to be %d", w.Code, tc.code)
+				}
+				if w.Body.String() != tc.response {
+					t.Errorf("the output %q was expected to be %q", w.Body.String(), tc.response)
+				}
+				if !reflect.DeepEqual(controller.Stored, tc.stored) {
+					t.Errorf("stored items %v were expected to be %v", controller.Stored, tc.stored)
+				}
+			})
+		}
+		t.Run("should abort with 413 if the body exceeds the limit while streaming", func(t *testing.T) {
+			limited := NewHandler(WithLogger(DiscardLogger), WithCodecs(CodecList{&json.JSON{}}), WithBodyLimit(64))
+			limited.Use("test", module)
+			body := `[{"id":"1"},{"id":"2"},{"id":"3"},{"id":"4"},{"id":"5"},{"id":"6"}]`
+			r := httptest.NewRequest(http.MethodPost, "/test/models", nil)
+			r.Body, r.ContentLength = ioutil.NopCloser(strings.NewReader(body)), -1
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			limited.ServeHTTP(w, r)
+			if w.Code != http.StatusRequestEntityTooLarge || w.Body.String() != "request body is too large (limit is 64 bytes)\n" {
+				t.Errorf("unexpected response %d %q", w.Code, w.Body.String())
+			}
+		})
+		t.Run("should reject the item with unknown field if strict", func(t *testing.T) {
+			controller.Stored = nil
+			strict := NewHandler(WithLogger(DiscardLogger), WithCodecs(CodecList{&json.JSON{}}), WithStrictDecoding(true))
+			strict.Use("test", module)
+			r := httptest.NewRequest(http.MethodPost, "/test/models", strings.NewReader(`[{"id":"1"}, {"id":"2","foo":"bar"}, {"id":"3"}]`))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			strict.ServeHTTP(w, r)
+			expected := `{"accepted":2,"rejected":[{"index":1,"code":400,"error":"json: unknown field \"foo\""}]}` + "\n"
+			if w.Code != http.StatusOK || w.Body.String() != expected {
+				t.Errorf("unexpected response %d %q", w.Code, w.Body.String())
+			}
+			if !reflect.DeepEqual(controller.Stored, []string{"1", "3"}) {
+				t.Errorf("stored items %v were expected to be [1 3]", controller.Stored)
+			}
+		})
+		t.Run("should render the error of the action if the item has not been decoded", func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/test/failing", strings.NewReader(`[{"id":"1"}]`))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != http.StatusForbidden || w.Body.String() != "not allowed\n" {
+				t.Errorf("unexpected response %d %q", w.Code, w.Body.String())
+			}
+		})
+	})
+}
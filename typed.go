@@ -0,0 +1,336 @@
+package lite
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+)
+
+var (
+	_ SingleGetter  = &TypedController{}
+	_ PluralGetter  = &TypedController{}
+	_ SinglePoster  = &TypedController{}
+	_ PluralPoster  = &TypedController{}
+	_ SinglePatcher = &TypedController{}
+	_ PluralPatcher = &TypedController{}
+	_ SinglePutter  = &TypedController{}
+	_ PluralPutter  = &TypedController{}
+	_ SingleDeleter = &TypedController{}
+	_ PluralDeleter = &TypedController{}
+)
+
+// TypedSingleGetter is a type-safe version of SingleGetter.
+type TypedSingleGetter[Out any] interface {
+	Controller
+	Get(ctx context.Context, pk string) (Out, error)
+}
+
+// TypedPluralGetter is a type-safe version of PluralGetter.
+type TypedPluralGetter[Out any] interface {
+	Controller
+	GetAll(ctx context.Context, params url.Values) (Out, error)
+}
+
+// TypedSinglePoster is a type-safe version of SinglePoster.
+type TypedSinglePoster[In, Out any] interface {
+	Controller
+	Post(ctx context.Context, in In) (Out, error)
+}
+
+// TypedPluralPoster is a type-safe version of PluralPoster.
+type TypedPluralPoster[In, Out any] interface {
+	Controller
+	PostAll(ctx context.Context, in In) (Out, error)
+}
+
+// TypedSinglePatcher is a type-safe version of SinglePatcher.
+type TypedSinglePatcher[In, Out any] interface {
+	Controller
+	Patch(ctx context.Context, pk string, in In) (Out, error)
+}
+
+// TypedPluralPatcher is a type-safe version of PluralPatcher.
+type TypedPluralPatcher[In, Out any] interface {
+	Controller
+	PatchAll(ctx context.Context, params url.Values, in In) (Out, error)
+}
+
+// TypedSinglePutter is a type-safe version of SinglePutter.
+type TypedSinglePutter[In, Out any] interface {
+	Controller
+	Put(ctx context.Context, pk string, in In) (Out, error)
+}
+
+// TypedPluralPutter is a type-safe version of PluralPutter.
+type TypedPluralPutter[In, Out any] interface {
+	Controller
+	PutAll(ctx context.Context, params url.Values, in In) (Out, error)
+}
+
+// TypedSingleDeleter is a type-safe version of SingleDeleter.
+type TypedSingleDeleter[Out any] interface {
+	Controller
+	Delete(ctx context.Context, pk string) (Out, error)
+}
+
+// TypedPluralDeleter is a type-safe version of PluralDeleter.
+type TypedPluralDeleter[Out any] interface {
+	Controller
+	DeleteAll(ctx context.Context, params url.Values) (Out, error)
+}
+
+// TypedAction adds type-safe action to the TypedController.
+type TypedAction func(*TypedController)
+
+// TypedController adapts type-safe actions to regular controller interfaces.
+// Request body is decoded into the input type of the action automatically and
+// only provided actions are mounted by the handler.
+//
+// Usage:
+//
+//	lite.NewTypedController(c, lite.TypedGet(c.Get), lite.TypedPost(c.Post))
+type TypedController struct {
+	Controller
+	get       func(context.Context, string) (interface{}, error)
+	getAll    func(context.Context, url.Values) (interface{}, error)
+	post      func(context.Context, func(interface{}) error) (interface{}, error)
+	postAll   func(context.Context, func(interface{}) error) (interface{}, error)
+	patch     func(context.Context, string, func(interface{}) error) (interface{}, error)
+	patchAll  func(context.Context, url.Values, func(interface{}) error) (interface{}, error)
+	put       func(context.Context, string, func(interface{}) error) (interface{}, error)
+	putAll    func(context.Context, url.Values, func(interface{}) error) (interface{}, error)
+	delete    func(context.Context, string) (interface{}, error)
+	deleteAll func(context.Context, url.Values) (interface{}, error)
+	// models contains request/response types of the actions
+	models map[string][2]reflect.Type
+}
+
+// NewTypedController is a constructor func for TypedController, base controller
+// provides middleware, Init func and receives dependencies.
+func NewTypedController(base Controller, actions ...TypedAction) *TypedController {
+	tc := &TypedController{Controller: base, models: make(map[string][2]reflect.Type)}
+	for _, action := range actions {
+		action(tc)
+	}
+	return tc
+}
+
+// TypedGet adapts type-safe Get action.
+func TypedGet[Out any](f func(context.Context, string) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["Get"] = [2]reflect.Type{nil, typeOf[Out]()}
+		tc.get = func(ctx context.Context, pk string) (interface{}, error) { return f(ctx, pk) }
+	}
+}
+
+// TypedGetAll adapts type-safe GetAll action.
+func TypedGetAll[Out any](f func(context.Context, url.Values) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["GetAll"] = [2]reflect.Type{nil, typeOf[Out]()}
+		tc.getAll = func(ctx context.Context, ps url.Values) (interface{}, error) { return f(ctx, ps) }
+	}
+}
+
+// TypedPost adapts type-safe Post action.
+func TypedPost[In, Out any](f func(context.Context, In) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["Post"] = [2]reflect.Type{typeOf[In](), typeOf[Out]()}
+		tc.post = func(ctx context.Context, cf func(interface{}) error) (interface{}, error) {
+			var in In
+			if err := cf(&in); err != nil {
+				return nil, err
+			}
+			return f(ctx, in)
+		}
+	}
+}
+
+// TypedPostAll adapts type-safe PostAll action.
+func TypedPostAll[In, Out any](f func(context.Context, In) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["PostAll"] = [2]reflect.Type{typeOf[In](), typeOf[Out]()}
+		tc.postAll = func(ctx context.Context, cf func(interface{}) error) (interface{}, error) {
+			var in In
+			if err := cf(&in); err != nil {
+				return nil, err
+			}
+			return f(ctx, in)
+		}
+	}
+}
+
+// TypedPatch adapts type-safe Patch action.
+func TypedPatch[In, Out any](f func(context.Context, string, In) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["Patch"] = [2]reflect.Type{typeOf[In](), typeOf[Out]()}
+		tc.patch = func(ctx context.Context, pk string, cf func(interface{}) error) (interface{}, error) {
+			var in In
+			if err := cf(&in); err != nil {
+				return nil, err
+			}
+			return f(ctx, pk, in)
+		}
+	}
+}
+
+// TypedPatchAll adapts type-safe PatchAll action.
+func TypedPatchAll[In, Out any](f func(context.Context, url.Values, In) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["PatchAll"] = [2]reflect.Type{typeOf[In](), typeOf[Out]()}
+		tc.patchAll = func(ctx context.Context, ps url.Values, cf func(interface{}) error) (interface{}, error) {
+			var in In
+			if err := cf(&in); err != nil {
+				return nil, err
+			}
+			return f(ctx, ps, in)
+		}
+	}
+}
+
+// TypedPut adapts type-safe Put action.
+func TypedPut[In, Out any](f func(context.Context, string, In) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["Put"] = [2]reflect.Type{typeOf[In](), typeOf[Out]()}
+		tc.put = func(ctx context.Context, pk string, cf func(interface{}) error) (interface{}, error) {
+			var in In
+			if err := cf(&in); err != nil {
+				return nil, err
+			}
+			return f(ctx, pk, in)
+		}
+	}
+}
+
+// TypedPutAll adapts type-safe PutAll action.
+func TypedPutAll[In, Out any](f func(context.Context, url.Values, In) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["PutAll"] = [2]reflect.Type{typeOf[In](), typeOf[Out]()}
+		tc.putAll = func(ctx context.Context, ps url.Values, cf func(interface{}) error) (interface{}, error) {
+			var in In
+			if err := cf(&in); err != nil {
+				return nil, err
+			}
+			return f(ctx, ps, in)
+		}
+	}
+}
+
+// TypedDelete adapts type-safe Delete action.
+func TypedDelete[Out any](f func(context.Context, string) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["Delete"] = [2]reflect.Type{nil, typeOf[Out]()}
+		tc.delete = func(ctx context.Context, pk string) (interface{}, error) { return f(ctx, pk) }
+	}
+}
+
+// TypedDeleteAll adapts type-safe DeleteAll action.
+func TypedDeleteAll[Out any](f func(context.Context, url.Values) (Out, error)) TypedAction {
+	return func(tc *TypedController) {
+		tc.models["DeleteAll"] = [2]reflect.Type{nil, typeOf[Out]()}
+		tc.deleteAll = func(ctx context.Context, ps url.Values) (interface{}, error) { return f(ctx, ps) }
+	}
+}
+
+// Get calls adapted Get action.
+func (tc *TypedController) Get(ctx context.Context, pk string) (interface{}, error) {
+	return tc.get(ctx, pk)
+}
+
+// GetAll calls adapted GetAll action.
+func (tc *TypedController) GetAll(ctx context.Context, ps url.Values) (interface{}, error) {
+	return tc.getAll(ctx, ps)
+}
+
+// Post calls adapted Post action.
+func (tc *TypedController) Post(ctx context.Context, f func(v interface{}) error) (interface{}, error) {
+	return tc.post(ctx, f)
+}
+
+// PostAll calls adapted PostAll action.
+func (tc *TypedController) PostAll(ctx context.Context, f func(v interface{}) error) (interface{}, error) {
+	return tc.postAll(ctx, f)
+}
+
+// Patch calls adapted Patch action.
+func (tc *TypedController) Patch(ctx context.Context, pk string, f func(v interface{}) error) (interface{}, error) {
+	return tc.patch(ctx, pk, f)
+}
+
+// PatchAll calls adapted PatchAll action.
+func (tc *TypedController) PatchAll(ctx context.Context, ps url.Values, f func(v interface{}) error) (interface{}, error) {
+	return tc.patchAll(ctx, ps, f)
+}
+
+// Put calls adapted Put action.
+func (tc *TypedController) Put(ctx context.Context, pk string, f func(v interface{}) error) (interface{}, error) {
+	return tc.put(ctx, pk, f)
+}
+
+// PutAll calls adapted PutAll action.
+func (tc *TypedController) PutAll(ctx context.Context, ps url.Values, f func(v interface{}) error) (interface{}, error) {
+	return tc.putAll(ctx, ps, f)
+}
+
+// Delete calls adapted Delete action.
+func (tc *TypedController) Delete(ctx context.Context, pk string) (interface{}, error) {
+	return tc.delete(ctx, pk)
+}
+
+// DeleteAll calls adapted DeleteAll action.
+func (tc *TypedController) DeleteAll(ctx context.Context, ps url.Values) (interface{}, error) {
+	return tc.deleteAll(ctx, ps)
+}
+
+// Describe describes the action using the base controller (if it implements
+// Describer) and request/response types of the adapted action.
+func (tc *TypedController) Describe(action string) *Description {
+	desc := &Description{}
+	if describer, ok := tc.Controller.(Describer); ok {
+		if d := describer.Describe(action); d != nil {
+			copied := *d
+			desc = &copied
+		}
+	}
+	if models, ok := tc.models[action]; ok {
+		if desc.Request == nil && models[0] != nil {
+			desc.Request = reflect.Zero(models[0]).Interface()
+		}
+		if desc.Response == nil && models[1] != nil {
+			desc.Response = reflect.Zero(models[1]).Interface()
+		}
+	}
+	return desc
+}
+
+// unwrap returns the base controller (to inject dependencies).
+func (tc *TypedController) unwrap() Controller { return tc.Controller }
+
+// implements reports whether the action has been provided.
+func (tc *TypedController) implements(action string) bool {
+	switch action {
+	case "Get":
+		return tc.get != nil
+	case "GetAll":
+		return tc.getAll != nil
+	case "Post":
+		return tc.post != nil
+	case "PostAll":
+		return tc.postAll != nil
+	case "Patch":
+		return tc.patch != nil
+	case "PatchAll":
+		return tc.patchAll != nil
+	case "Put":
+		return tc.put != nil
+	case "PutAll":
+		return tc.putAll != nil
+	case "Delete":
+		return tc.delete != nil
+	case "DeleteAll":
+		return tc.deleteAll != nil
+	}
+	return false
+}
+
+// typeOf returns reflection type of the type parameter.
+func typeOf[T any]() reflect.Type { return reflect.TypeOf((*T)(nil)).Elem() }
@@ -0,0 +1,66 @@
+package lite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	mw "github.com/tiny-go/middleware"
+)
+
+var (
+	_ TypedSingleGetter[string]                           = &mockTypedController{}
+	_ TypedSinglePoster[*mockTypedInput, *mockTypedInput] = &mockTypedController{}
+)
+
+func Test_TypedController(t *testing.T) {
+	t.Run("Given an HTTP handler with typed controller", func(t *testing.T) {
+		driver.Default("application/json")
+		controller := &mockTypedController{Controller: mw.NewBaseController()}
+		module := NewBaseModule()
+		module.Register("typed", NewTypedController(
+			controller,
+			TypedGet(controller.Get),
+			TypedPost(controller.Post),
+		))
+		handler := NewHandler(WithLogger(DiscardLogger))
+		handler.Map("!")
+		if err := handler.Use("test", module); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		t.Run("should inject dependencies to the base controller", func(t *testing.T) {
+			if controller.Suffix != "!" {
+				t.Errorf("dependency was not injected: %q", controller.Suffix)
+			}
+		})
+		t.Run("should mount only provided actions", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/test/typed/abcd", nil))
+			if w.Header().Get("Access-Control-Allow-Methods") != "GET,POST" {
+				t.Errorf("unexpected list of methods %q", w.Header().Get("Access-Control-Allow-Methods"))
+			}
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/typed", nil))
+			if w.Code == http.StatusOK {
+				t.Error("plural GET should not be available")
+			}
+		})
+		t.Run("should call typed action", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/typed/abcd", nil))
+			if w.Body.String() != "\"abcd!\"\n" {
+				t.Errorf("unexpected response %q", w.Body.String())
+			}
+		})
+		t.Run("should decode request body to the typed input", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test/typed/abcd", strings.NewReader("{\"foo\":\"bar\"}")))
+			if w.Body.String() != "{\"foo\":\"bar!\"}\n" {
+				t.Errorf("unexpected response %q", w.Body.String())
+			}
+		})
+	})
+}
@@ -0,0 +1,236 @@
+package lite
+
+import (
+	"bufio"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/tiny-go/codec"
+	"github.com/tiny-go/errors"
+	mw "github.com/tiny-go/middleware"
+)
+
+const (
+	// sniffLen is the number of bytes used to detect the content type.
+	sniffLen = 512
+	// defaultMaxMemory is the size of multipart form kept in memory (the rest is
+	// stored in temporary files).
+	defaultMaxMemory = 32 << 20
+)
+
+// Upload is a decode target exposing raw request body (for instance an image or
+// a document). Configure the limit before passing it to the decode func:
+//
+//	upload := &lite.Upload{MaxSize: 5 << 20}
+//	if err := f(upload); err != nil {
+//		return nil, err
+//	}
+type Upload struct {
+	// MaxSize limits the size of the body (reading more responds with 413), zero
+	// means no limit.
+	MaxSize int64
+	// ContentType of the body (detected by the content if not provided).
+	ContentType string
+	// Size of the body (-1 if unknown).
+	Size int64
+	// Body reads the request body.
+	Body io.Reader
+}
+
+// Form is a decode target exposing multipart form values and files. Parts that
+// do not fit into memory are stored in temporary files (removed after the action).
+type Form struct {
+	// MaxSize limits the size of the body (413 if exceeded), zero means no limit.
+	MaxSize int64
+	// MaxMemory is the size of the form stored in memory (32 MB by default).
+	MaxMemory int64
+	// Values contains form values by field names.
+	Values url.Values
+	// Files contains uploaded files by field names.
+	Files map[string][]*File
+}
+
+// File is a single file of multipart form.
+type File struct {
+	// Filename provided by the client.
+	Filename string
+	// ContentType of the file (detected by the content if not provided).
+	ContentType string
+	// Size of the file.
+	Size int64
+
+	header *multipart.FileHeader
+}
+
+// Open opens the file (stored in memory or in a temporary file).
+func (f *File) Open() (multipart.File, error) { return f.header.Open() }
+
+// decoder returns the func that decodes request body into provided value using
+// request codec (or exposes the body if value is Upload or Form) and the func that
+// releases resources (temporary files) once the action is finished.
+func decoder(r *http.Request) (func(v interface{}) error, func()) {
+	var form *multipart.Form
+	decode := func(v interface{}) error {
+		switch target := v.(type) {
+		case *Upload:
+			return target.read(r)
+		case *Form:
+			err := target.parse(r)
+			form = r.MultipartForm
+			return err
+		}
+		return decode(mw.RequestCodecFromContext(r.Context()), r.Body, decodingFromContext(r.Context()).strict(), v)
+	}
+	return decode, func() {
+		if form != nil {
+			form.RemoveAll()
+		}
+	}
+}
+
+// read exposes the request body.
+func (u *Upload) read(r *http.Request) error {
+	body := io.Reader(r.Body)
+	if u.MaxSize > 0 {
+		if r.ContentLength > u.MaxSize {
+			return tooLarge(u.MaxSize)
+		}
+		body = newLimitedReader(body, u.MaxSize)
+	}
+	buffered := bufio.NewReaderSize(body, sniffLen)
+	u.Size, u.Body = r.ContentLength, buffered
+	if u.ContentType = r.Header.Get("Content-Type"); u.ContentType == "" || u.ContentType == "application/octet-stream" {
+		// detect content type by the first bytes of the body
+		data, err := buffered.Peek(sniffLen)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return err
+		}
+		u.ContentType = http.DetectContentType(data)
+	}
+	return nil
+}
+
+// parse parses multipart form of the request.
+func (f *Form) parse(r *http.Request) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return errors.NewStatusError(http.StatusUnsupportedMediaType,
+			fmt.Errorf("multipart form was expected, got %q", r.Header.Get("Content-Type")))
+	}
+	if f.MaxSize > 0 {
+		if r.ContentLength > f.MaxSize {
+			return tooLarge(f.MaxSize)
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{newLimitedReader(r.Body, f.MaxSize), r.Body}
+	}
+	maxMemory := f.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = defaultMaxMemory
+	}
+	if err := r.ParseMultipartForm(maxMemory); err != nil {
+		var statusErr errors.Error
+		if stderrors.As(err, &statusErr) {
+			return statusErr
+		}
+		return errors.NewStatusError(http.StatusBadRequest, err)
+	}
+	f.Values, f.Files = url.Values(r.MultipartForm.Value), make(map[string][]*File)
+	for field, headers := range r.MultipartForm.File {
+		for _, header := range headers {
+			file := &File{Filename: header.Filename, ContentType: header.Header.Get("Content-Type"), Size: header.Size, header: header}
+			if file.ContentType == "" || file.ContentType == "application/octet-stream" {
+				if file.ContentType, err = sniff(header); err != nil {
+					return err
+				}
+			}
+			f.Files[field] = append(f.Files[field], file)
+		}
+	}
+	return nil
+}
+
+// sniff detects content type of the file.
+func sniff(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, data)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return http.DetectContentType(data[:n]), nil
+}
+
+// tooLarge returns 413 error.
+func tooLarge(limit int64) error {
+	return errors.NewStatusError(http.StatusRequestEntityTooLarge,
+		fmt.Errorf("request body is too large (limit is %d bytes)", limit))
+}
+
+// limitedReader reads at most n bytes and returns 413 error if there is more data.
+type limitedReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+// newLimitedReader creates limitedReader.
+func newLimitedReader(r io.Reader, limit int64) *limitedReader {
+	return &limitedReader{r: r, n: limit, limit: limit}
+}
+
+// Read reads the data while the limit is not exceeded.
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if lr.n <= 0 {
+		// check if there is more data
+		var b [1]byte
+		if n, err := io.ReadAtLeast(lr.r, b[:], 1); n > 0 {
+			return 0, tooLarge(lr.limit)
+		} else if err != nil {
+			return 0, err
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > lr.n {
+		p = p[:lr.n]
+	}
+	n, err := lr.r.Read(p)
+	lr.n -= int64(n)
+	return n, err
+}
+
+// unsupportedCodec is used as request codec if Content-Type is not supported by
+// the codecs, so the body can still be read as Upload or Form (decoding it with
+// the codec responds with 415).
+type unsupportedCodec struct{ contentType string }
+
+// Encoder returns the encoder that always fails.
+func (c *unsupportedCodec) Encoder(io.Writer) codec.Encoder {
+	return codec.EncoderFunc(func(interface{}) error { return c.err() })
+}
+
+// Decoder returns the decoder that always fails.
+func (c *unsupportedCodec) Decoder(io.Reader) codec.Decoder {
+	return codec.DecoderFunc(func(interface{}) error { return c.err() })
+}
+
+// MimeType returns Content-Type of the request.
+func (c *unsupportedCodec) MimeType() string { return c.contentType }
+
+// err returns 415 error.
+func (c *unsupportedCodec) err() error {
+	return errors.NewStatusError(http.StatusUnsupportedMediaType,
+		fmt.Errorf("unsupported content type: %q", c.contentType))
+}
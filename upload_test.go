@@ -0,0 +1,108 @@
+package lite
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tiny-go/codec/driver"
+	_ "github.com/tiny-go/codec/driver/json"
+	mw "github.com/tiny-go/middleware"
+)
+
+func Test_Upload(t *testing.T) {
+	t.Run("Given a handler with upload controller", func(t *testing.T) {
+		driver.Default("application/json")
+		controller := &mockUploadController{Controller: mw.NewBaseController()}
+		module := NewBaseModule()
+		module.Register("files", controller)
+		handler := NewHandler(WithLogger(DiscardLogger))
+		handler.Use("test", module)
+
+		form := func(withFile bool, content string) (string, *bytes.Buffer) {
+			body := &bytes.Buffer{}
+			mpw := multipart.NewWriter(body)
+			mpw.WriteField("title", "report")
+			if withFile {
+				part, _ := mpw.CreateFormFile("attachment", "report.csv")
+				part.Write([]byte(content))
+			}
+			mpw.Close()
+			return mpw.FormDataContentType(), body
+		}
+		cases := []struct {
+			title       string
+			method      string
+			contentType string
+			body        func() (string, *bytes.Buffer)
+			code        int
+			response    string
+		}{
+			{
+				"should expose raw body with provided content type", http.MethodPost, "",
+				func() (string, *bytes.Buffer) { return "text/csv", bytes.NewBufferString("a,b\n1,2\n") },
+				http.StatusCreated, `{"data":"a,b\n1,2\n","type":"text/csv"}` + "\n",
+			},
+			{
+				"should detect content type of raw body", http.MethodPost, "",
+				func() (string, *bytes.Buffer) { return "", bytes.NewBufferString("\x89PNG\x0d\x0a\x1a\x0a") },
+				http.StatusCreated, `{"data":"�PNG\r\n\u001a\n","type":"image/png"}` + "\n",
+			},
+			{
+				"should reject too large body", http.MethodPost, "",
+				func() (string, *bytes.Buffer) { return "text/plain", bytes.NewBufferString(strings.Repeat("x", 17)) },
+				http.StatusRequestEntityTooLarge, "request body is too large (limit is 16 bytes)\n",
+			},
+			{
+				"should expose multipart form values and files", http.MethodPut, "",
+				func() (string, *bytes.Buffer) { return form(true, "a,b\n") },
+				http.StatusOK, `{"files":{"attachment":"report.csv:text/plain; charset=utf-8:a,b\n"},"values":{"title":["report"]}}` + "\n",
+			},
+			{
+				"should reject too large form", http.MethodPut, "",
+				func() (string, *bytes.Buffer) { return form(true, strings.Repeat("x", 2048)) },
+				http.StatusRequestEntityTooLarge, "request body is too large (limit is 1024 bytes)\n",
+			},
+			{
+				"should reject body which is not a form", http.MethodPut, "",
+				func() (string, *bytes.Buffer) { return "text/plain", bytes.NewBufferString("foo") },
+				http.StatusUnsupportedMediaType, "multipart form was expected, got \"text/plain\"\n",
+			},
+		}
+		for _, tc := range cases {
+			t.Run(tc.title, func(t *testing.T) {
+				contentType, body := tc.body()
+				r := httptest.NewRequest(tc.method, "/test/files/1", body)
+				if contentType != "" {
+					r.Header.Set("Content-Type", contentType)
+				}
+				r.Header.Set("Accept", "application/json")
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tc.code {
+					t.Errorf("status code %d was expected to be %d", w.Code, tc.code)
+				}
+				if w.Body.String() != tc.response {
+					t.Errorf("the output %q was expected to be %q", w.Body.String(), tc.response)
+				}
+			})
+		}
+		t.Run("should remove temporary files after the action", func(t *testing.T) {
+			controller.Files = nil
+			contentType, body := form(true, strings.Repeat("x", 512))
+			r := httptest.NewRequest(http.MethodPut, "/test/files/1", body)
+			r.Header.Set("Content-Type", contentType)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			if len(controller.Files) != 1 {
+				t.Fatalf("one file was expected but got %d", len(controller.Files))
+			}
+			if f, err := controller.Files[0].Open(); err == nil {
+				f.Close()
+				t.Error("temporary file was expected to be removed")
+			}
+		})
+	})
+}